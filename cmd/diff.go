@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ervinmplayon/tractatus/internal/output"
+	"github.com/ervinmplayon/tractatus/internal/snapshot"
+)
+
+// Compares two saved snapshots: tractatus diff [--from previous] [--to latest]
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	snapshotDir := flags.String("snapshot-dir", snapshot.DefaultDir, "Directory snapshots were saved to")
+	from := flags.String("from", "previous", "Older snapshot: latest, previous, a snapshot ID or a snapshot file path")
+	to := flags.String("to", "latest", "Newer snapshot: latest, previous, a snapshot ID or a snapshot file path")
+	formatFlag := flags.String("format", "table", "Output format: "+strings.Join(output.DiffFormats, ", "))
+	outputFlag := flags.String("output", "stdout", "Output destination: stdout or file path")
+	flags.Parse(args)
+
+	if !slices.Contains(output.DiffFormats, *formatFlag) {
+		log.Fatalf("Error: Unknown format '%s'. Use %s", *formatFlag, strings.Join(output.DiffFormats, ", "))
+	}
+
+	store := snapshot.NewStore(*snapshotDir)
+	older, err := store.Resolve(*from)
+	if err != nil {
+		log.Fatalf("Failed to load --from snapshot: %v", err)
+	}
+	newer, err := store.Resolve(*to)
+	if err != nil {
+		log.Fatalf("Failed to load --to snapshot: %v", err)
+	}
+
+	diff := snapshot.Compare(older, newer)
+	if err := output.NewDiffWriter(*formatFlag, *outputFlag).Write(diff); err != nil {
+		log.Fatalf("Failed to write diff: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%d added, %d removed, %d changed between %s and %s\n",
+		len(diff.Added), len(diff.Removed), len(diff.Changed), older.ID, newer.ID)
+}
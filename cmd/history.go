@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ervinmplayon/tractatus/internal/output"
+	"github.com/ervinmplayon/tractatus/internal/snapshot"
+)
+
+// Reports coverage metrics across saved snapshots: tractatus history [--since 2026-01-01] [--last 12]
+func runHistory(args []string) {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	snapshotDir := flags.String("snapshot-dir", snapshot.DefaultDir, "Directory snapshots were saved to")
+	sinceFlag := flags.String("since", "", "Only snapshots taken on or after this date (YYYY-MM-DD)")
+	last := flags.Int("last", 0, "Only the most recent N snapshots (0 = all)")
+	formatFlag := flags.String("format", "markdown", "Output format: "+strings.Join(output.HistoryFormats, ", "))
+	outputFlag := flags.String("output", "stdout", "Output destination: stdout or file path")
+	flags.Parse(args)
+
+	if !slices.Contains(output.HistoryFormats, *formatFlag) {
+		log.Fatalf("Error: Unknown format '%s'. Use %s", *formatFlag, strings.Join(output.HistoryFormats, ", "))
+	}
+
+	var since time.Time
+	if *sinceFlag != "" {
+		var err error
+		since, err = time.Parse("2006-01-02", *sinceFlag)
+		if err != nil {
+			log.Fatalf("Error: --since must be a date like 2026-01-31: %v", err)
+		}
+	}
+
+	snaps, err := snapshot.NewStore(*snapshotDir).LoadSince(since)
+	if err != nil {
+		log.Fatalf("Failed to load snapshots: %v", err)
+	}
+	if *last > 0 && len(snaps) > *last {
+		snaps = snaps[len(snaps)-*last:]
+	}
+	if len(snaps) == 0 {
+		log.Fatalf("Error: No snapshots found in %s (save some with --save-snapshot)", *snapshotDir)
+	}
+
+	if err := output.NewHistoryWriter(*formatFlag, *outputFlag).Write(output.BuildHistory(snaps)); err != nil {
+		log.Fatalf("Failed to write history: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nReported %d snapshots from %s to %s\n",
+		len(snaps), snaps[0].ID, snaps[len(snaps)-1].ID)
+}
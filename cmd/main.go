@@ -6,92 +6,223 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/ervinmplayon/tractatus/internal/cache"
 	"github.com/ervinmplayon/tractatus/internal/config"
 	"github.com/ervinmplayon/tractatus/internal/inventory"
 	"github.com/ervinmplayon/tractatus/internal/output"
+	"github.com/ervinmplayon/tractatus/internal/snapshot"
 	awssource "github.com/ervinmplayon/tractatus/internal/sources/aws"
 	githubsource "github.com/ervinmplayon/tractatus/internal/sources/github"
 )
 
 func main() {
+	// Subcommands take their own flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		case "history":
+			runHistory(os.Args[2:])
+			return
+		}
+	}
+
 	// Define CLI flags
-	source := flag.String("source", "github", "Data source: github, aws")
+	source := flag.String("source", "github", "Data source(s): github, aws (comma-separated for several, e.g. github,aws)")
+	correlate := flag.Bool("correlate", false, "Collect GitHub and AWS together and report one row per service (implies --source github,aws)")
 
 	// GitHub flags
 	githubOrg := flag.String("github-org", "", "GitHub organization name")
 	githubToken := flag.String("github-token", "", "GitHub personal access token (or use GITHUB_TOKEN env var)")
+	githubAppID := flag.Int64("github-app-id", 0, "Authenticate as this GitHub App instead of with a token (needs --github-app-key)")
+	githubAppInstallation := flag.Int64("github-app-installation-id", 0, "GitHub App installation ID; looked up from --github-org if omitted")
+	githubAppKey := flag.String("github-app-key", "", "Path to the GitHub App private key (.pem)")
+	githubURL := flag.String("github-url", "", "GitHub Enterprise Server URL, e.g. https://github.example.com (default github.com)")
+	githubUploadURL := flag.String("github-upload-url", "", "GitHub Enterprise Server upload URL (defaults to --github-url)")
 	excludeArchived := flag.Bool("exclude-archived", true, "Exclude archived repositories")
+	workers := flag.Int("workers", githubsource.DefaultWorkers, "Number of repositories analyzed concurrently")
+	recursive := flag.Bool("recursive", false, "Scan the full repository tree instead of root entries only. Without it, reading CI configs costs up to 3 extra tree calls per repo (.github, .github/workflows, .circleci); with it, one tree call covers everything")
+	maxDepth := flag.Int("max-depth", githubsource.DefaultMaxDepth, "Deepest path level scanned with --recursive (1 = root)")
+	validateCodeOwners := flag.Bool("validate-codeowners", false, "Report CODEOWNERS errors GitHub finds (invalid patterns, unknown owners, owners without write access); one extra API call per repo")
+	includeEKS := flag.Bool("include-eks", false, "Inventory EKS/Kubernetes workloads (labelled EKS/Kubernetes) instead of skipping them")
+	deployUnits := flag.Bool("deploy-units", false, "Also list each deployable subdirectory of a repo (monorepos) as its own resource; use with --recursive")
+	rulesPath := flag.String("rules", "", "JSON file of detection rules, merged over the built-in ones (see rules.example.json)")
+	resolveOwners := flag.Bool("resolve-owners", false, "Resolve CODEOWNERS teams and users through the GitHub API and flag stale owners")
+	githubBackend := flag.String("github-backend", githubsource.BackendREST, "How repo metadata is fetched: rest, or graphql (many repos per query, fewer calls)")
 
 	// AWS flags
-	accountsFlag := flag.String("account", "", "AWS account name(s) from config (comma-separated for multiple)")
+	accountsFlag := flag.String("account", "", "AWS account name(s) from config (comma-separated for multiple, or 'all')")
 	useProfile := flag.Bool("use-profile", true, "Use AWS credential profiles instead of config.json")
+	regionsFlag := flag.String("regions", "", "AWS region(s) to scan (comma-separated, or 'all' for every enabled region). Defaults to the account's region")
 	configPath := flag.String("config", "config.json", "Path to config file")
 
+	// Cache flags
+	noCache := flag.Bool("no-cache", false, "Always fetch fresh data; don't read or write the response cache")
+	refresh := flag.Bool("refresh", false, "Ignore the cache TTL and re-fetch (GitHub still sends ETags, so unchanged data costs no rate limit)")
+	cacheDir := flag.String("cache-dir", cache.DefaultDir(), "Directory for cached GitHub and AWS responses")
+	cacheTTL := flag.Duration("cache-ttl", cache.DefaultTTL, "How long cached responses are used without asking the API again (e.g. 30m, 6h)")
+
 	// Output flags
-	formatFlag := flag.String("format", "table", "Output format: table, markdown")
+	formatFlag := flag.String("format", "table", "Output format: table, markdown, json, ndjson, csv, confluence")
 	outputFlag := flag.String("output", "stdout", "Output destination: stdout or file path")
+	csvTags := flag.Bool("csv-tags", false, "Add a tag:<key> column per AWS tag to CSV output")
+
+	// Snapshot flags (compare runs with: tractatus diff)
+	saveSnapshot := flag.Bool("save-snapshot", false, "Save the collected inventory as a timestamped snapshot")
+	snapshotDir := flag.String("snapshot-dir", snapshot.DefaultDir, "Directory snapshots are saved to")
+	baselineFlag := flag.String("baseline", "", "Incremental GitHub run: reuse results for repos not pushed since this inventory (latest, a snapshot ID, or a snapshot/JSON output file)")
+
+	// Confluence flags (--format confluence)
+	confluenceURL := flag.String("confluence-url", "", "Confluence base URL, e.g. https://example.atlassian.net/wiki")
+	confluenceSpace := flag.String("confluence-space", "", "Confluence space key to publish into")
+	confluenceParent := flag.String("confluence-parent", "", "Optional parent page ID")
+	confluenceTitle := flag.String("confluence-title", "Resource Inventory", "Confluence page title (updated in place if it exists)")
+	confluenceUser := flag.String("confluence-user", "", "Confluence Cloud user email (basic auth with the token); leave empty to send the token as a bearer PAT")
+	confluenceToken := flag.String("confluence-token", "", "Confluence API token or PAT (or use CONFLUENCE_TOKEN env var)")
 
 	flag.Parse()
 
-	var dataSource inventory.DataSource
+	var dataSources []inventory.DataSource
+	var collectionOptions map[string]string // saved with the snapshot and JSON output, so a later --baseline can tell how it was collected
 	var err error
 
-	// Determine the DataSource here: github vs aws.
-	switch *source {
-	case "github":
-		// Get token from 1. flag or 2. environment variable (backup)
+	var responseCache *cache.Cache
+	if !*noCache {
+		responseCache = cache.New(*cacheDir, *cacheTTL, *refresh)
+	}
+
+	sourceNames := parseList(*source)
+	if *correlate {
+		sourceNames = parseList("github,aws," + *source)
+	}
+	wanted := make(map[string]bool)
+	for _, name := range sourceNames {
+		if name != "github" && name != "aws" {
+			log.Fatalf("Error: Unknown source '%s'. Use 'github', 'aws' or 'github,aws'", name)
+		}
+		wanted[name] = true
+	}
+
+	// Determine the DataSources here: github, aws, or both.
+	if wanted["github"] {
+		// Get token from 1. flag or 2. environment variable (backup), unless authenticating as an app
 		token := *githubToken
 		if token == "" {
 			token = os.Getenv("GITHUB_TOKEN")
 		}
-		if token == "" {
-			log.Fatal("Error: GitHub token required. Use --github-token flag or set GITHUB_TOKEN environment variable")
+		var app *githubsource.AppAuth
+		if *githubAppID != 0 {
+			if *githubAppKey == "" {
+				log.Fatal("Error: --github-app-key is required with --github-app-id")
+			}
+			key, err := githubsource.LoadPrivateKey(*githubAppKey)
+			if err != nil {
+				log.Fatalf("Failed to load GitHub App key: %v", err)
+			}
+			app = &githubsource.AppAuth{AppID: *githubAppID, InstallationID: *githubAppInstallation, PrivateKey: key}
+		} else if token == "" {
+			log.Fatal("Error: GitHub token required. Use --github-token flag, set GITHUB_TOKEN environment variable, or use --github-app-id with --github-app-key")
+		}
+		if *githubBackend != githubsource.BackendREST && *githubBackend != githubsource.BackendGraphQL {
+			log.Fatalf("Error: Unknown GitHub backend '%s'. Use 'rest' or 'graphql'", *githubBackend)
+		}
+		if *deployUnits && !*recursive {
+			fmt.Fprintf(os.Stderr, "Warning: --deploy-units only sees root files without --recursive\n")
 		}
+
+		var rules []*githubsource.Rule
+		if *rulesPath != "" {
+			rules, err = githubsource.LoadRules(*rulesPath)
+			if err != nil {
+				log.Fatalf("Failed to load detection rules: %v", err)
+			}
+		}
+
+		githubOpts := githubsource.Options{
+			ExcludeArchived:    *excludeArchived,
+			Workers:            *workers,
+			Recursive:          *recursive,
+			MaxDepth:           *maxDepth,
+			ResolveOwners:      *resolveOwners,
+			ValidateCodeOwners: *validateCodeOwners,
+			Rules:              rules,
+			DeployUnits:        *deployUnits,
+			IncludeEKS:         *includeEKS,
+			Cache:              responseCache,
+			Backend:            *githubBackend,
+			App:                app,
+			BaseURL:            *githubURL,
+			UploadURL:          *githubUploadURL,
+		}
+		collectionOptions = githubOpts.Settings()
+
+		if *baselineFlag != "" {
+			snap, err := snapshot.NewStore(*snapshotDir).Resolve(*baselineFlag)
+			if err != nil {
+				log.Fatalf("Failed to load baseline: %v", err)
+			}
+			githubOpts.Baseline = snap.Inventory
+			githubOpts.BaselineSettings = snap.Options
+			fmt.Fprintf(os.Stderr, "Using baseline %s for unchanged repos\n", snap.ID)
+		}
+
 		fmt.Fprintf(os.Stderr, "Collecting inventory from Github org: %s\n", *githubOrg)
-		dataSource, err = githubsource.NewDataSource(token, *githubOrg, *excludeArchived)
+		dataSource, err := githubsource.NewDataSource(token, *githubOrg, githubOpts)
 		if err != nil {
 			log.Fatalf("Failed to create Github data source: %v", err)
 		}
+		dataSources = append(dataSources, dataSource)
+	}
 
-	case "aws":
+	if wanted["aws"] {
 		if *accountsFlag == "" {
 			log.Fatal("Error: --account flag is required for AWS source")
 		}
 
-		// Load configuration if not using profiles
+		// Load configuration if not using profiles. "all" needs the config for the account list either way.
 		var cfg *config.Config
-		if !*useProfile {
+		if !*useProfile || *accountsFlag == "all" {
 			cfg, err = config.LoadConfig(*configPath)
 			if err != nil {
 				log.Fatalf("Failed to load config: %v", err)
 			}
 		}
 
-		// Support is limited to single account (extend to multiple later)
-		accountName := *accountsFlag
-		var account *config.Account
-		if cfg != nil {
-			if acc, exists := cfg.Accounts[accountName]; !exists {
-				log.Fatalf("Error: Account '%s' not found in config", accountName)
-			} else {
-				account = &acc
-			}
+		accountNames, err := parseAccounts(*accountsFlag, cfg)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
-		fmt.Fprintf(os.Stderr, "Collecting inventory from AWS account: %s\n", accountName)
 		if *useProfile {
 			fmt.Fprintf(os.Stderr, "Using AWS credential profiles from ~/.aws/\n")
 		}
-		dataSource = awssource.NewDataSource(accountName, account, *useProfile)
+		regions := parseList(*regionsFlag)
+		if len(regions) > 1 && slices.Contains(regions, "all") {
+			log.Fatal("Error: --regions all can't be combined with other regions")
+		}
 
-	default:
-		log.Fatalf("Error: Unknown source '%s'. Use 'github' or 'aws'", *source)
+		for _, accountName := range accountNames {
+			var account *config.Account
+			if cfg != nil && !*useProfile {
+				acc := cfg.Accounts[accountName]
+				account = &acc
+			}
+			fmt.Fprintf(os.Stderr, "Collecting inventory from AWS account: %s\n", accountName)
+			dataSources = append(dataSources, awssource.NewDataSource(accountName, account, *useProfile, regions, *includeEKS, responseCache))
+		}
 	}
 
 	// Collect inventory
 	collector := inventory.NewCollector()
-	ctx := context.Background()
-	result, err := collector.CollectFromSource(ctx, dataSource)
+	// Ctrl-C cancels in-flight collection instead of leaving workers running
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result, err := collector.CollectFromSources(ctx, dataSources)
 	if err != nil {
 		log.Fatalf("Failed to collect inventory: %v", err)
 	}
@@ -99,6 +230,20 @@ func main() {
 		log.Fatal("Error: No resources found")
 	}
 
+	// Link AWS resources to the repos they were deployed from
+	if *correlate {
+		result.Services = inventory.Correlate(result.Resources)
+	}
+
+	// Save before writing so a failed publish doesn't lose the run
+	if *saveSnapshot {
+		snap, err := snapshot.NewStore(*snapshotDir).Save(result, sourceNames, collectionOptions)
+		if err != nil {
+			log.Fatalf("Failed to save snapshot: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Saved snapshot %s to %s\n", snap.ID, *snapshotDir)
+	}
+
 	// Create appropriate output writer
 	var writer output.OutputWriter
 	switch *formatFlag {
@@ -114,8 +259,42 @@ func main() {
 		} else {
 			writer = output.NewFileMarkdownWriter(*outputFlag)
 		}
+	case "json":
+		if *outputFlag == "stdout" {
+			writer = output.NewStdoutJSONWriter(collectionOptions)
+		} else {
+			writer = output.NewFileJSONWriter(*outputFlag, collectionOptions)
+		}
+	case "ndjson":
+		if *outputFlag == "stdout" {
+			writer = output.NewStdoutNDJSONWriter()
+		} else {
+			writer = output.NewFileNDJSONWriter(*outputFlag)
+		}
+	case "csv":
+		if *outputFlag == "stdout" {
+			writer = output.NewStdoutCSVWriter(*csvTags)
+		} else {
+			writer = output.NewFileCSVWriter(*outputFlag, *csvTags)
+		}
+	case "confluence":
+		token := *confluenceToken
+		if token == "" {
+			token = os.Getenv("CONFLUENCE_TOKEN")
+		}
+		if *confluenceURL == "" || *confluenceSpace == "" || token == "" {
+			log.Fatal("Error: --confluence-url, --confluence-space and a token (--confluence-token or CONFLUENCE_TOKEN) are required for confluence output")
+		}
+		writer = output.NewConfluenceWriter(output.ConfluenceConfig{
+			BaseURL:  *confluenceURL,
+			SpaceKey: *confluenceSpace,
+			ParentID: *confluenceParent,
+			Title:    *confluenceTitle,
+			Username: *confluenceUser,
+			Token:    token,
+		})
 	default:
-		log.Fatalf("Error: Unknown format '%s'. Use 'table' or 'markdown'", *formatFlag)
+		log.Fatalf("Error: Unknown format '%s'. Use 'table', 'markdown', 'json', 'ndjson', 'csv' or 'confluence'", *formatFlag)
 	}
 
 	// Write output
@@ -123,6 +302,55 @@ func main() {
 		log.Fatalf("Failed to write output: %v", err)
 	}
 
+	if *correlate {
+		fmt.Fprintf(os.Stderr, "\nSuccessfully correlated %d resources into %d services\n",
+			len(result.Resources), len(result.Services))
+		return
+	}
 	fmt.Fprintf(os.Stderr, "\nSuccessfully processed %d resources from %s\n",
-		len(result.Resources), *source)
+		len(result.Resources), strings.Join(sourceNames, ", "))
+}
+
+// Expands the --account flag into account names. "all" means every account in the config.
+func parseAccounts(accountsFlag string, cfg *config.Config) ([]string, error) {
+	if accountsFlag == "all" {
+		if cfg == nil {
+			return nil, fmt.Errorf("--account all requires a config file")
+		}
+		names := make([]string, 0, len(cfg.Accounts))
+		for name := range cfg.Accounts {
+			names = append(names, name)
+		}
+		sort.Strings(names) // map order is random, keep the report stable
+		return names, nil
+	}
+
+	names := parseList(accountsFlag)
+	if cfg != nil {
+		for _, name := range names {
+			if _, exists := cfg.Accounts[name]; !exists {
+				return nil, fmt.Errorf("account '%s' not found in config", name)
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no account names given in --account")
+	}
+	return names, nil
+}
+
+// Splits a comma-separated flag value, dropping blanks and duplicates
+func parseList(value string) []string {
+	var items []string
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		items = append(items, item)
+	}
+	return items
 }
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// How long an entry is served without asking the API again
+const DefaultTTL = time.Hour
+
+// On-disk cache of API responses, one JSON file per request key.
+// Failing to read or write the cache never fails a run: it falls back to fetching.
+// A nil *Cache caches nothing.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	refresh bool // treat every entry as stale; they are still used for conditional requests
+
+	warnOnce sync.Once
+}
+
+// Stored form of one entry
+type entry struct {
+	Key      string          `json:"key"`
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// Returns a cache in dir. With refresh set, nothing is served without going back to the API.
+func New(dir string, ttl time.Duration, refresh bool) *Cache {
+	return &Cache{dir: dir, ttl: ttl, refresh: refresh}
+}
+
+// Returns the per-user cache directory, or .tractatus/cache when the OS has none
+func DefaultDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "tractatus")
+	}
+	return filepath.Join(".tractatus", "cache")
+}
+
+// Decodes the entry for key into value.
+// found reports whether there was an entry; fresh whether it is younger than the TTL (and not refreshing).
+func (c *Cache) Get(key string, value any) (fresh, found bool) {
+	if c == nil {
+		return false, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return false, false
+	}
+
+	var stored entry
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Key != key {
+		return false, false // corrupt, or a different key in the same file: refetch
+	}
+	if err := json.Unmarshal(stored.Value, value); err != nil {
+		return false, false
+	}
+
+	fresh = !c.refresh && time.Since(stored.StoredAt) < c.ttl
+	return fresh, true
+}
+
+// Stores value under key, stamped now
+func (c *Cache) Put(key string, value any) {
+	if c == nil {
+		return
+	}
+	if err := c.put(key, value); err != nil {
+		c.warnOnce.Do(func() {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write response cache: %v (further cache write errors not shown)\n", err)
+		})
+	}
+}
+
+func (c *Cache) put(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("[put] error: %w", err)
+	}
+	data, err := json.Marshal(entry{Key: key, StoredAt: time.Now().UTC(), Value: raw})
+	if err != nil {
+		return fmt.Errorf("[put] error: %w", err)
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("[put] error: %w", err)
+	}
+
+	// Temp file and rename: concurrent workers and interrupted runs never leave a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("[put] error: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("[put] error: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("[put] error: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("[put] error: %w", err)
+	}
+	return nil
+}
+
+// Maps a key to its file, fanned out over 256 subdirectories
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, name[:2], name+".json")
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 )
 
 // Config represents the application configuration
@@ -13,11 +14,12 @@ type Config struct {
 
 // Represents a single AWS application configuration
 type Account struct {
-	AccountID       string `json:"account_id"`
-	Region          string `json:"region"`
-	AccessKeyID     string `json:"access_key_id"`
-	SecretAccessKey string `json:"secret_access_key"`
-	SessionToken    string `json:"session_token,omitempty"`
+	AccountID       string   `json:"account_id"`
+	Region          string   `json:"region"`
+	Regions         []string `json:"regions,omitempty"` // regions to scan instead of just Region; ["all"] for every enabled region
+	AccessKeyID     string   `json:"access_key_id"`
+	SecretAccessKey string   `json:"secret_access_key"`
+	SessionToken    string   `json:"session_token,omitempty"`
 }
 
 var LoadConfig = func(filepath string) (*Config, error) {
@@ -54,6 +56,9 @@ var LoadConfig = func(filepath string) (*Config, error) {
 		if account.SessionToken == "" {
 			return nil, fmt.Errorf("account '%s' missing session_token", name)
 		}
+		if len(account.Regions) > 1 && slices.Contains(account.Regions, "all") {
+			return nil, fmt.Errorf("account '%s' regions: 'all' can't be combined with other regions", name)
+		}
 	}
 
 	return &config, nil
@@ -3,9 +3,11 @@ package inventory
 import (
 	"context"
 	"fmt"
+	"os"
+	"sync"
 )
 
-// Manages resource collection form multiple AWS accounts
+// Manages resource collection from multiple data sources (GitHub orgs, AWS accounts)
 type Collector struct{}
 
 func NewCollector() *Collector {
@@ -14,35 +16,119 @@ func NewCollector() *Collector {
 
 // Represents the complete inventory of resources
 type Inventory struct {
-	Resources []*ResourceInfo
+	Resources []*ResourceInfo `json:"resources"`
+	Services  []*Service      `json:"services,omitempty"` // set when GitHub and AWS resources were correlated
+	Skipped   []Skipped       `json:"skipped,omitempty"`  // what the sources deliberately left out
 }
 
-// Represents enriched resource information
+// A resource a source left out of the inventory, or only partly inspected, and why
+type Skipped struct {
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"` // "EKS", the value of the exclude rule that matched, or "partially scanned"
+}
+
+// Represents enriched resource information.
+// The json tags are the published JSON/NDJSON schema (see output.SchemaVersion); rename a tag only with a version bump.
 type ResourceInfo struct {
 	// Common fields
-	AppName  string
-	Owner    string
-	Team     string
-	Platform string
+	Source   string `json:"source"` // Name() of the DataSource that produced it: "GitHub", "AWS"
+	AppName  string `json:"app_name"`
+	Owner    string `json:"owner"`
+	Team     string `json:"team"`
+	Platform string `json:"platform"`
 
 	// AWS-specific fields
-	StackName    string
-	HasCICD      bool
-	Account      string
-	ARN          string
-	ResourceTags map[string]string // Keep all tags for reference
+	StackName    string            `json:"stack_name"`
+	HasCICD      bool              `json:"has_cicd"`
+	Account      string            `json:"account"`
+	Region       string            `json:"region"`
+	ARN          string            `json:"arn"`
+	ResourceTags map[string]string `json:"resource_tags"` // Keep all tags for reference
 
 	// GitHub-specific fields
-	GitHubRepo     string
-	LastCommitter  string
-	LastCommitDate string
-	HasCodeOwners  bool
-	CodeOwners     []string
-	HasTests       bool
-	TestFramework  string // "pytest", "jest", "go test", etc.
-	CICDPlatform   string // "CircleCI", "GitHub Actions", "CloudFormation", etc.
-	RepoURL        string
-	IsArchived     bool
+	GitHubRepo         string              `json:"github_repo"`
+	LastCommitter      string              `json:"last_committer"`
+	LastCommitDate     string              `json:"last_commit_date"`
+	PushedAt           string              `json:"pushed_at"` // last push to any branch (RFC 3339); incremental runs compare it
+	HasCodeOwners      bool                `json:"has_codeowners"`
+	CodeOwners         []string            `json:"codeowners"`
+	RootOwners         []string            `json:"root_owners"`        // owners of the catch-all (*) rule
+	PathOwners         map[string][]string `json:"path_owners"`        // deployable subdirectory -> its CODEOWNERS owners
+	UnownedPaths       []string            `json:"unowned_paths"`      // paths no CODEOWNERS rule covers
+	OwnerDetails       []OwnerDetail       `json:"owner_details"`      // CODEOWNERS entries resolved against the GitHub API (--resolve-owners)
+	CodeOwnersErrors   []CodeOwnersError   `json:"codeowners_errors"`  // problems GitHub reports with the CODEOWNERS file
+	CodeOwnersChecked  bool                `json:"codeowners_checked"` // GitHub validated the CODEOWNERS file (--validate-codeowners)
+	HasTests           bool                `json:"has_tests"`
+	TestFramework      string              `json:"test_framework"`      // "pytest", "jest", "go test", etc. (comma-separated when several)
+	TestFrameworks     []string            `json:"test_frameworks"`     // the same frameworks as a list
+	CICDPlatform       string              `json:"cicd_platform"`       // "CircleCI", "GitHub Actions", "CloudFormation", etc. (comma-separated when several)
+	CICDPlatforms      []string            `json:"cicd_platforms"`      // the same systems as a list
+	CICDEvidence       map[string][]string `json:"cicd_evidence"`       // system -> the paths it was detected from
+	PlatformConfidence string              `json:"platform_confidence"` // how sure the Platform guess is, one of the Confidence* levels
+	DeployEnvironments []string            `json:"deploy_environments"` // environments CI deploys to ("staging", "production")
+	DeployEvidence     []string            `json:"deploy_evidence"`     // what the Platform is based on: "<rule> in <file>"
+	RepoURL            string              `json:"repo_url"`
+	KubernetesApps     []string            `json:"kubernetes_apps"` // app names from Helm charts and kustomizations (--include-eks)
+	ParentRepo         string              `json:"parent_repo"`     // set on deployable units: the repo they live in
+	UnitPath           string              `json:"unit_path"`       // set on deployable units: their directory in ParentRepo
+	IsArchived         bool                `json:"is_archived"`
+}
+
+// Returns the identity a resource keeps across runs: the repo (and unit path) for GitHub, the ARN for AWS.
+// Services refer to resources by it.
+func (r *ResourceInfo) Key() string {
+	if r.GitHubRepo != "" {
+		if r.UnitPath != "" {
+			return "github:" + r.GitHubRepo + "/" + r.UnitPath
+		}
+		return "github:" + r.GitHubRepo
+	}
+	if r.ARN != "" {
+		return "aws:" + r.ARN
+	}
+	return fmt.Sprintf("aws:%s/%s/%s", r.Account, r.Region, r.AppName)
+}
+
+// Platform confidence levels
+const (
+	ConfidenceHigh   = "high"   // a CI pipeline deploys there
+	ConfidenceMedium = "medium" // platform-specific config is present (task definition, serverless.yml)
+	ConfidenceLow    = "low"    // only a generic hint (a Dockerfile)
+)
+
+// Platform label for Kubernetes workloads, from either source
+const PlatformEKS = "EKS/Kubernetes"
+
+// Owner resolution statuses
+const (
+	OwnerOK         = "ok"
+	OwnerNotFound   = "not_found"  // user or team doesn't exist (deleted, renamed, or not visible to the token)
+	OwnerNotInOrg   = "not_in_org" // user exists but has left the org, or team belongs to another org
+	OwnerEmptyTeam  = "empty_team" // team exists but nobody is in it
+	OwnerUnverified = "unverified" // email owners can't be checked through the API
+	OwnerLookupFail = "lookup_failed"
+)
+
+// What the GitHub API says about a single CODEOWNERS entry
+type OwnerDetail struct {
+	Handle      string   `json:"handle"` // as written in CODEOWNERS, without "@"
+	Kind        string   `json:"kind"`   // "user", "team" or "email"
+	Status      string   `json:"status"` // one of the Owner* statuses
+	DisplayName string   `json:"display_name,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	Members     []string `json:"members,omitempty"` // team member logins
+}
+
+// A CODEOWNERS problem reported by GitHub: an invalid pattern, an unknown owner, or an owner without write access
+type CodeOwnersError struct {
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	Kind       string `json:"kind"`   // "Invalid pattern", "Unknown owner", ...
+	Source     string `json:"source"` // the offending CODEOWNERS line
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+	Path       string `json:"path"` // which CODEOWNERS file
 }
 
 type DataSource interface {
@@ -50,6 +136,12 @@ type DataSource interface {
 	Name() string
 }
 
+// Optionally implemented by data sources that leave resources out (EKS workloads), so runs can say what was skipped.
+// Called after Collect.
+type SkipReporter interface {
+	Skipped() []Skipped
+}
+
 // Collects inventory from a single data source
 func (c *Collector) CollectFromSource(ctx context.Context, source DataSource) (*Inventory, error) {
 	resources, err := source.Collect(ctx)
@@ -57,9 +149,59 @@ func (c *Collector) CollectFromSource(ctx context.Context, source DataSource) (*
 		return nil, fmt.Errorf("error [CollectFromSource()] %s: %w", source.Name(), err)
 	}
 
-	return &Inventory{
+	for _, res := range resources {
+		if res.Source == "" {
+			res.Source = source.Name()
+		}
+	}
+
+	inv := &Inventory{
 		Resources: resources,
-	}, nil
+	}
+	if reporter, ok := source.(SkipReporter); ok {
+		inv.Skipped = reporter.Skipped()
+		for i := range inv.Skipped {
+			if inv.Skipped[i].Source == "" {
+				inv.Skipped[i].Source = source.Name()
+			}
+		}
+	}
+
+	return inv, nil
+}
+
+// Collects inventory from several data sources concurrently and merges the results in the order the sources were given.
+// A source that fails is reported on stderr and skipped, so one bad account doesn't sink the whole run.
+func (c *Collector) CollectFromSources(ctx context.Context, sources []DataSource) (*Inventory, error) {
+	results := make([]*Inventory, len(sources))
+	errs := make([]error, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source DataSource) {
+			defer wg.Done()
+			results[i], errs[i] = c.CollectFromSource(ctx, source)
+		}(i, source)
+	}
+	wg.Wait()
+
+	var inventories []*Inventory
+	var failed int
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			failed++
+			continue
+		}
+		inventories = append(inventories, results[i])
+	}
+
+	if len(sources) > 0 && failed == len(sources) {
+		return nil, fmt.Errorf("error [CollectFromSources()] all %d sources failed", failed)
+	}
+
+	return MergeInventories(inventories), nil
 }
 
 // Combines multiple inventories into one
@@ -70,6 +212,7 @@ func MergeInventories(inventories []*Inventory) *Inventory {
 
 	for _, inv := range inventories {
 		merged.Resources = append(merged.Resources, inv.Resources...)
+		merged.Skipped = append(merged.Skipped, inv.Skipped...)
 	}
 
 	return merged
@@ -0,0 +1,294 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tag keys that name the repository a resource was deployed from, checked in order
+var repoTagKeys = []string{"repo", "git-repo", "github-repo", "repository", "source-repo"}
+
+// How an AWS resource was linked to a repository
+const (
+	MatchByRepoTag   = "repo tag"
+	MatchByStackName = "stack name"
+	MatchByName      = "name"
+)
+
+// One service: a repository and the AWS resources deployed from it.
+// Resources that couldn't be linked to any repo are grouped by app name with Repo left nil.
+// JSON refers to the repo and resources by Key() instead of repeating rows already in the inventory's resources.
+type Service struct {
+	Name         string        `json:"name"`
+	Repo         *ResourceInfo `json:"-"`
+	RepoKey      string        `json:"repo,omitempty"` // Repo.Key()
+	Owner        string        `json:"owner"`
+	Team         string        `json:"team"`
+	CICDPlatform string        `json:"cicd_platform"`
+	HasTests     bool          `json:"has_tests"`
+	Deployments  []Deployment  `json:"deployments"`
+	Accounts     []string      `json:"accounts"`
+}
+
+// An AWS resource attributed to a service, and which signal linked it
+type Deployment struct {
+	Resource    *ResourceInfo `json:"-"`
+	ResourceKey string        `json:"resource"`   // Resource.Key()
+	MatchedBy   string        `json:"matched_by"` // MatchByRepoTag, MatchByStackName, MatchByName, or "" when unlinked
+}
+
+// Reads a service, including ones saved by schema version 1, which embedded the repo row instead of its key
+func (s *Service) UnmarshalJSON(data []byte) error {
+	type plain Service // same fields, without this method
+	var raw struct {
+		plain
+		Repo json.RawMessage `json:"repo"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	key, err := resourceRef(raw.Repo)
+	if err != nil {
+		return fmt.Errorf("service %s: repo: %w", raw.Name, err)
+	}
+	*s = Service(raw.plain)
+	s.RepoKey = key
+	return nil
+}
+
+// Reads a deployment, including version 1 ones that embedded the resource row
+func (d *Deployment) UnmarshalJSON(data []byte) error {
+	type plain Deployment
+	var raw struct {
+		plain
+		Resource json.RawMessage `json:"resource"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	key, err := resourceRef(raw.Resource)
+	if err != nil {
+		return fmt.Errorf("deployment: resource: %w", err)
+	}
+	*d = Deployment(raw.plain)
+	d.ResourceKey = key
+	return nil
+}
+
+// Returns the key a service reference holds: the string itself, or the Key() of an embedded row
+func resourceRef(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	if raw[0] == '"' {
+		var key string
+		err := json.Unmarshal(raw, &key)
+		return key, err
+	}
+	var res ResourceInfo
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return "", err
+	}
+	return res.Key(), nil
+}
+
+// Points decoded services back at the inventory's rows by key: JSON only keeps RepoKey and ResourceKey.
+// References to rows the inventory doesn't hold stay nil.
+func (inv *Inventory) LinkServices() {
+	if len(inv.Services) == 0 {
+		return
+	}
+
+	byKey := make(map[string]*ResourceInfo, len(inv.Resources))
+	for _, res := range inv.Resources {
+		if _, exists := byKey[res.Key()]; !exists {
+			byKey[res.Key()] = res
+		}
+	}
+	for _, svc := range inv.Services {
+		if svc.RepoKey != "" {
+			svc.Repo = byKey[svc.RepoKey]
+		}
+		for i := range svc.Deployments {
+			svc.Deployments[i].Resource = byKey[svc.Deployments[i].ResourceKey]
+		}
+	}
+}
+
+// Links AWS resources to GitHub repositories and returns one Service per repo (in input order),
+// followed by services for AWS resources no repo claimed (sorted by name).
+//
+// A resource is linked by, in order of trust:
+//  1. a repo/git-repo/... tag naming the repo (URL, org/name or bare name)
+//  2. its CloudFormation stack name equal to the repo or starting with "<repo>-"
+//  3. its Name tag equal to the repo or starting with "<repo> - " / "<repo>-"
+//
+// For 2 and 3 the longest matching repo name wins, so "api-gateway-prod" goes to api-gateway rather than api.
+func Correlate(resources []*ResourceInfo) []*Service {
+	var repos, awsResources []*ResourceInfo
+	for _, res := range resources {
+		if res.UnitPath != "" {
+			continue // monorepo units belong to their repo's service
+		}
+		if res.GitHubRepo != "" {
+			repos = append(repos, res)
+		} else {
+			awsResources = append(awsResources, res)
+		}
+	}
+
+	services := make([]*Service, 0, len(repos))
+	byRepo := make(map[string]*Service)
+	for _, repo := range repos {
+		key := normalizeRepoName(repo.GitHubRepo)
+		if _, exists := byRepo[key]; exists {
+			continue
+		}
+		svc := &Service{
+			Name:         repo.GitHubRepo,
+			Repo:         repo,
+			RepoKey:      repo.Key(),
+			Owner:        repo.Owner,
+			Team:         repo.Team,
+			CICDPlatform: repo.CICDPlatform,
+			HasTests:     repo.HasTests,
+		}
+		byRepo[key] = svc
+		services = append(services, svc)
+	}
+
+	// Longest names first so prefix matching prefers the most specific repo
+	repoKeys := make([]string, 0, len(byRepo))
+	for key := range byRepo {
+		repoKeys = append(repoKeys, key)
+	}
+	sort.Slice(repoKeys, func(i, j int) bool {
+		if len(repoKeys[i]) != len(repoKeys[j]) {
+			return len(repoKeys[i]) > len(repoKeys[j])
+		}
+		return repoKeys[i] < repoKeys[j]
+	})
+
+	unlinked := make(map[string]*Service)
+	for _, res := range awsResources {
+		key, matchedBy := matchRepo(res, byRepo, repoKeys)
+		if key == "" {
+			svc, exists := unlinked[res.AppName]
+			if !exists {
+				svc = &Service{Name: res.AppName, Owner: res.Owner, Team: res.Team}
+				unlinked[res.AppName] = svc
+			}
+			svc.Deployments = append(svc.Deployments, Deployment{Resource: res, ResourceKey: res.Key()})
+			continue
+		}
+		svc := byRepo[key]
+		svc.Deployments = append(svc.Deployments, Deployment{Resource: res, ResourceKey: res.Key(), MatchedBy: matchedBy})
+	}
+
+	names := make([]string, 0, len(unlinked))
+	for name := range unlinked {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		services = append(services, unlinked[name])
+	}
+
+	for _, svc := range services {
+		svc.finish()
+	}
+
+	return services
+}
+
+// Fills the derived fields once all deployments are attached
+func (s *Service) finish() {
+	seen := make(map[string]bool)
+	for _, dep := range s.Deployments {
+		res := dep.Resource
+		if res.Account != "" && !seen[res.Account] {
+			seen[res.Account] = true
+			s.Accounts = append(s.Accounts, res.Account)
+		}
+
+		// Repo-less services (or repos without CODEOWNERS) borrow ownership from the resource tags
+		if (s.Owner == "" || s.Owner == "Unknown") && res.Owner != "" && res.Owner != "Unknown" {
+			s.Owner = res.Owner
+		}
+		if (s.Team == "" || s.Team == "Unknown") && res.Team != "" && res.Team != "Unknown" {
+			s.Team = res.Team
+		}
+	}
+	sort.Strings(s.Accounts)
+
+	if s.Owner == "" {
+		s.Owner = "Unknown"
+	}
+	if s.Team == "" {
+		s.Team = "Unknown"
+	}
+}
+
+// Finds the repo a resource belongs to; returns the repo key and the signal that matched
+func matchRepo(res *ResourceInfo, byRepo map[string]*Service, repoKeys []string) (string, string) {
+	for _, tagKey := range repoTagKeys {
+		for key, value := range res.ResourceTags {
+			if !strings.EqualFold(key, tagKey) {
+				continue
+			}
+			if name := normalizeRepoName(value); byRepo[name] != nil {
+				return name, MatchByRepoTag
+			}
+		}
+	}
+
+	stack := res.StackName
+	if stack == "" || stack == "None" {
+		stack = res.ResourceTags["aws:cloudformation:stack-name"]
+	}
+	if key := matchPrefix(normalizeRepoName(stack), repoKeys, "-", "_"); key != "" {
+		return key, MatchByStackName
+	}
+
+	name := res.ResourceTags["Name"]
+	if name == "" {
+		name = res.AppName
+	}
+	if key := matchPrefix(strings.ToLower(strings.TrimSpace(name)), repoKeys, " - ", "-", "_", " "); key != "" {
+		return key, MatchByName
+	}
+
+	return "", ""
+}
+
+// Returns the first (longest) repo key that equals value or is a prefix of it followed by one of the separators
+func matchPrefix(value string, repoKeys []string, separators ...string) string {
+	if value == "" {
+		return ""
+	}
+	for _, key := range repoKeys {
+		if value == key {
+			return key
+		}
+		for _, sep := range separators {
+			if strings.HasPrefix(value, key+sep) {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// Reduces a repo reference to a comparable name:
+// "https://github.com/org/Unity-API.git", "org/unity-api" and "unity-api" all become "unity-api"
+func normalizeRepoName(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	value = strings.TrimSuffix(value, "/")
+	value = strings.TrimSuffix(value, ".git")
+	if i := strings.LastIndexAny(value, "/:"); i >= 0 {
+		value = value[i+1:]
+	}
+	return value
+}
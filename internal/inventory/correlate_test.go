@@ -0,0 +1,140 @@
+package inventory
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRepoName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"unity-api", "unity-api"},
+		{"  Unity-API ", "unity-api"},
+		{"org/unity-api", "unity-api"},
+		{"https://github.com/org/Unity-API.git", "unity-api"},
+		{"https://github.com/org/unity-api/", "unity-api"},
+		{"git@github.com:org/unity-api.git", "unity-api"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeRepoName(tt.in); got != tt.want {
+			t.Errorf("normalizeRepoName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRepo(t *testing.T) {
+	repos := []string{"api", "api-gateway", "billing"}
+	byRepo := make(map[string]*Service)
+	for _, name := range repos {
+		byRepo[name] = &Service{Name: name}
+	}
+	repoKeys := []string{"api-gateway", "billing", "api"} // longest first, as Correlate sorts them
+
+	tests := []struct {
+		name      string
+		res       *ResourceInfo
+		wantRepo  string
+		wantMatch string
+	}{
+		{
+			name:      "repo tag as URL",
+			res:       &ResourceInfo{AppName: "x", ResourceTags: map[string]string{"Repo": "https://github.com/org/billing.git"}},
+			wantRepo:  "billing",
+			wantMatch: MatchByRepoTag,
+		},
+		{
+			name:      "repo tag wins over stack name",
+			res:       &ResourceInfo{StackName: "api-prod", ResourceTags: map[string]string{"git-repo": "org/billing"}},
+			wantRepo:  "billing",
+			wantMatch: MatchByRepoTag,
+		},
+		{
+			name:      "unknown repo tag falls through to stack name",
+			res:       &ResourceInfo{StackName: "billing-prod", ResourceTags: map[string]string{"repo": "elsewhere"}},
+			wantRepo:  "billing",
+			wantMatch: MatchByStackName,
+		},
+		{
+			name:      "longest stack prefix wins",
+			res:       &ResourceInfo{StackName: "api-gateway-prod"},
+			wantRepo:  "api-gateway",
+			wantMatch: MatchByStackName,
+		},
+		{
+			name:      "stack name from the CloudFormation tag",
+			res:       &ResourceInfo{StackName: "None", ResourceTags: map[string]string{"aws:cloudformation:stack-name": "api_staging"}},
+			wantRepo:  "api",
+			wantMatch: MatchByStackName,
+		},
+		{
+			name:      "name tag",
+			res:       &ResourceInfo{ResourceTags: map[string]string{"Name": "Billing - worker"}},
+			wantRepo:  "billing",
+			wantMatch: MatchByName,
+		},
+		{
+			name:      "app name when there is no name tag",
+			res:       &ResourceInfo{AppName: "api"},
+			wantRepo:  "api",
+			wantMatch: MatchByName,
+		},
+		{
+			name: "prefix needs a separator",
+			res:  &ResourceInfo{AppName: "apigee"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, matchedBy := matchRepo(tt.res, byRepo, repoKeys)
+			if repo != tt.wantRepo || matchedBy != tt.wantMatch {
+				t.Errorf("matchRepo = (%q, %q), want (%q, %q)", repo, matchedBy, tt.wantRepo, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestServiceJSONRefersToResources(t *testing.T) {
+	repo := &ResourceInfo{AppName: "billing", GitHubRepo: "billing"}
+	lambda := &ResourceInfo{AppName: "billing-worker", ARN: "arn:aws:lambda:us-east-1:1:function:billing-worker", StackName: "billing-prod"}
+	services := Correlate([]*ResourceInfo{repo, lambda})
+
+	data, err := json.Marshal(services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"app_name"`) {
+		t.Errorf("services embed resource rows: %s", data)
+	}
+
+	var decoded []*Service
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0].RepoKey != "github:billing" {
+		t.Fatalf("decoded services = %+v, want one for github:billing", decoded)
+	}
+	if deps := decoded[0].Deployments; len(deps) != 1 || deps[0].ResourceKey != "aws:"+lambda.ARN || deps[0].MatchedBy != MatchByStackName {
+		t.Errorf("deployments = %+v, want the lambda by stack name", deps)
+	}
+}
+
+func TestServiceJSONReadsEmbeddedRows(t *testing.T) {
+	// Schema version 1 embedded the rows
+	legacy := `{"name":"billing","repo":{"app_name":"billing","github_repo":"billing"},
+		"deployments":[{"resource":{"app_name":"w","arn":"arn:aws:lambda:us-east-1:1:function:w"},"matched_by":"name"}]}`
+
+	var svc Service
+	if err := json.Unmarshal([]byte(legacy), &svc); err != nil {
+		t.Fatal(err)
+	}
+	if svc.RepoKey != "github:billing" {
+		t.Errorf("RepoKey = %q, want github:billing", svc.RepoKey)
+	}
+	if len(svc.Deployments) != 1 || svc.Deployments[0].ResourceKey != "aws:arn:aws:lambda:us-east-1:1:function:w" {
+		t.Errorf("deployments = %+v", svc.Deployments)
+	}
+}
@@ -0,0 +1,296 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Connection and page settings for publishing to Confluence
+type ConfluenceConfig struct {
+	BaseURL  string // e.g. https://example.atlassian.net/wiki or https://confluence.example.com
+	SpaceKey string
+	ParentID string // optional page ID to nest the inventory page under
+	Title    string
+	Username string // set for Confluence Cloud (email + API token as basic auth); empty sends Token as a bearer PAT
+	Token    string
+}
+
+// Publishes the inventory as a Confluence page, updating it in place on later runs
+type ConfluenceWriter struct {
+	cfg        ConfluenceConfig
+	httpClient *http.Client
+}
+
+func NewConfluenceWriter(cfg ConfluenceConfig) *ConfluenceWriter {
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	return &ConfluenceWriter{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Subset of the Confluence content API payload we read and write
+type confluencePage struct {
+	ID        string               `json:"id,omitempty"`
+	Type      string               `json:"type"`
+	Title     string               `json:"title"`
+	Space     *confluenceSpace     `json:"space,omitempty"`
+	Ancestors []confluenceAncestor `json:"ancestors,omitempty"`
+	Version   *confluenceVersion   `json:"version,omitempty"`
+	Body      *confluenceBody      `json:"body,omitempty"`
+}
+
+type confluenceSpace struct {
+	Key string `json:"key"`
+}
+
+type confluenceAncestor struct {
+	ID string `json:"id"`
+}
+
+type confluenceVersion struct {
+	Number int `json:"number"`
+}
+
+type confluenceBody struct {
+	Storage confluenceStorage `json:"storage"`
+}
+
+type confluenceStorage struct {
+	Value          string `json:"value"`
+	Representation string `json:"representation"`
+}
+
+// Renders the inventory and creates the page, or bumps the version of the existing one with the same title
+func (w *ConfluenceWriter) Write(inv *inventory.Inventory) error {
+	if w.cfg.BaseURL == "" || w.cfg.SpaceKey == "" || w.cfg.Title == "" {
+		return fmt.Errorf("confluenceWriter: base URL, space key and title are required")
+	}
+
+	page := confluencePage{
+		Type:  "page",
+		Title: w.cfg.Title,
+		Space: &confluenceSpace{Key: w.cfg.SpaceKey},
+		Body: &confluenceBody{Storage: confluenceStorage{
+			Value:          renderConfluenceStorage(inv),
+			Representation: "storage",
+		}},
+	}
+	if w.cfg.ParentID != "" {
+		page.Ancestors = []confluenceAncestor{{ID: w.cfg.ParentID}}
+	}
+
+	existing, err := w.findPage()
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		page.Version = &confluenceVersion{Number: 1}
+		if err := w.do(http.MethodPost, "/rest/api/content", page, nil); err != nil {
+			return fmt.Errorf("confluenceWriter: failed to create page: %w", err)
+		}
+		return nil
+	}
+
+	// Confluence rejects an update unless the version is exactly current+1
+	page.ID = existing.ID
+	page.Version = &confluenceVersion{Number: existing.Version.Number + 1}
+	if err := w.do(http.MethodPut, "/rest/api/content/"+url.PathEscape(existing.ID), page, nil); err != nil {
+		return fmt.Errorf("confluenceWriter: failed to update page %s: %w", existing.ID, err)
+	}
+	return nil
+}
+
+// Looks up the page by space and title; returns nil if it doesn't exist yet
+func (w *ConfluenceWriter) findPage() (*confluencePage, error) {
+	query := url.Values{}
+	query.Set("spaceKey", w.cfg.SpaceKey)
+	query.Set("title", w.cfg.Title)
+	query.Set("type", "page")
+	query.Set("expand", "version")
+
+	var result struct {
+		Results []confluencePage `json:"results"`
+	}
+	if err := w.do(http.MethodGet, "/rest/api/content?"+query.Encode(), nil, &result); err != nil {
+		return nil, fmt.Errorf("confluenceWriter: failed to look up page: %w", err)
+	}
+
+	if len(result.Results) == 0 {
+		return nil, nil
+	}
+	page := result.Results[0]
+	if page.Version == nil {
+		return nil, fmt.Errorf("confluenceWriter: page %s returned without version", page.ID)
+	}
+	return &page, nil
+}
+
+// Sends a JSON request to the Confluence REST API and decodes the response into out (if non-nil)
+func (w *ConfluenceWriter) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, w.cfg.BaseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if w.cfg.Username != "" {
+		req.SetBasicAuth(w.cfg.Username, w.cfg.Token)
+	} else if w.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.cfg.Token)
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s returned %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// Renders the inventory as Confluence storage format (XHTML)
+func renderConfluenceStorage(inv *inventory.Inventory) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "<p><strong>Generated</strong>: %s</p>", html.EscapeString(time.Now().Format("2006-01-02 15:04:05")))
+
+	if len(inv.Resources) == 0 {
+		b.WriteString("<p>No resources found.</p>")
+		return b.String()
+	}
+
+	github, aws, other := splitBySource(inv)
+
+	switch {
+	case len(inv.Services) > 0:
+		b.WriteString("<h2>Services</h2>")
+		rows := serviceRows(inv)
+		writeConfluenceTable(&b, rows[0], rows[1:])
+	case len(github.Resources) == 0 && len(aws.Resources) == 0:
+		// only other sources, listed below
+	case len(aws.Resources) == 0:
+		writeGitHubConfluence(&b, github)
+	case len(github.Resources) == 0:
+		writeAWSConfluence(&b, aws)
+	default:
+		b.WriteString("<h1>GitHub</h1>")
+		writeGitHubConfluence(&b, github)
+		b.WriteString("<h1>AWS</h1>")
+		writeAWSConfluence(&b, aws)
+	}
+
+	// Sources without their own layout get the generic mixed columns
+	if len(inv.Services) == 0 && len(other.Resources) > 0 {
+		b.WriteString("<h1>Other Sources</h1>")
+		rows := mixedCSVRows(other, false)
+		writeConfluenceTable(&b, rows[0], rows[1:])
+	}
+
+	if len(inv.Skipped) > 0 {
+		b.WriteString("<h2>Skipped</h2><ul>")
+		for _, group := range groupSkipped(inv.Skipped) {
+			fmt.Fprintf(&b, "<li><strong>%s</strong>: %d (%s)</li>",
+				html.EscapeString(group.Source+", "+group.Reason), len(group.Names), html.EscapeString(formatSkippedNames(group.Names)))
+		}
+		b.WriteString("</ul>")
+	}
+	return b.String()
+}
+
+// Writes the GitHub summary and repository table
+func writeGitHubConfluence(b *strings.Builder, inv *inventory.Inventory) {
+	summary := generateGitHubSummary(inv)
+
+	b.WriteString("<h2>Summary</h2><ul>")
+	writeConfluenceItem(b, "Total Repositories", summary.TotalResources)
+	if summary.DeployableUnits > 0 {
+		writeConfluenceItem(b, "Deployable Units", summary.DeployableUnits)
+	}
+	for _, platform := range sortedKeys(summary.ByPlatform) {
+		writeConfluenceItem(b, platform, summary.ByPlatform[platform])
+	}
+	writeConfluenceItem(b, "With CI/CD", summary.WithCICD)
+	writeConfluenceItem(b, "With Tests", summary.WithTests)
+	writeConfluenceItem(b, "With CODEOWNERS", summary.WithCodeOwners)
+	if summary.CheckedCodeOwners > 0 {
+		writeConfluenceItem(b, "With CODEOWNERS errors", summary.InvalidCodeOwners)
+	}
+	b.WriteString("</ul>")
+
+	b.WriteString("<h2>Repositories</h2>")
+	rows := gitHubCSVRows(inv) // same columns as the markdown/CSV output
+	writeConfluenceTable(b, rows[0], rows[1:])
+}
+
+// Writes the AWS summary and resource table
+func writeAWSConfluence(b *strings.Builder, inv *inventory.Inventory) {
+	summary := generateAWSSummary(inv)
+
+	b.WriteString("<h2>Summary</h2><ul>")
+	writeConfluenceItem(b, "Total Resources", summary.TotalResources)
+	for _, platform := range sortedKeys(summary.ByPlatform) {
+		writeConfluenceItem(b, platform, summary.ByPlatform[platform])
+	}
+	writeConfluenceItem(b, "Resources with CI/CD", summary.WithCICD)
+	writeConfluenceItem(b, "Resources without CI/CD", summary.WithoutCICD)
+	b.WriteString("</ul>")
+
+	b.WriteString("<h2>Resources</h2>")
+	rows := awsCSVRows(&inventory.Inventory{Resources: sortByRegion(inv.Resources)}, false)
+	writeConfluenceTable(b, rows[0], rows[1:])
+}
+
+// Writes a single "<li><strong>label</strong>: count</li>" summary line
+func writeConfluenceItem(b *strings.Builder, label string, count int) {
+	fmt.Fprintf(b, "<li><strong>%s</strong>: %d</li>", html.EscapeString(label), count)
+}
+
+// Writes an escaped XHTML table
+func writeConfluenceTable(b *strings.Builder, header []string, rows [][]string) {
+	b.WriteString("<table><tbody><tr>")
+	for _, h := range header {
+		fmt.Fprintf(b, "<th>%s</th>", html.EscapeString(h))
+	}
+	b.WriteString("</tr>")
+
+	for _, row := range rows {
+		b.WriteString("<tr>")
+		for _, cell := range row {
+			fmt.Fprintf(b, "<td>%s</td>", html.EscapeString(cell))
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</tbody></table>")
+}
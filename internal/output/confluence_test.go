@@ -0,0 +1,142 @@
+package output
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Stub Confluence: answers the page lookup with existing (nil for none) and records writes
+type confluenceStub struct {
+	existing *confluencePage
+	status   int // returned for every request when set
+
+	created []confluencePage
+	updated map[string]confluencePage
+}
+
+func (s *confluenceStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.status != 0 {
+		http.Error(w, `{"message":"nope"}`, s.status)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/rest/api/content":
+		result := struct {
+			Results []confluencePage `json:"results"`
+		}{Results: []confluencePage{}}
+		if s.existing != nil && r.URL.Query().Get("title") == s.existing.Title {
+			result.Results = append(result.Results, *s.existing)
+		}
+		json.NewEncoder(w).Encode(result)
+
+	case r.Method == http.MethodPost && r.URL.Path == "/rest/api/content":
+		var page confluencePage
+		json.NewDecoder(r.Body).Decode(&page)
+		s.created = append(s.created, page)
+		w.Write([]byte(`{"id":"new"}`))
+
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/rest/api/content/"):
+		var page confluencePage
+		json.NewDecoder(r.Body).Decode(&page)
+		if s.updated == nil {
+			s.updated = make(map[string]confluencePage)
+		}
+		s.updated[strings.TrimPrefix(r.URL.Path, "/rest/api/content/")] = page
+		w.Write([]byte(`{}`))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func testConfluenceInventory() *inventory.Inventory {
+	return &inventory.Inventory{Resources: []*inventory.ResourceInfo{
+		{AppName: "api", GitHubRepo: "api", Source: "GitHub", Owner: "@acme/platform", HasCICD: true},
+	}}
+}
+
+func newTestConfluenceWriter(url string) *ConfluenceWriter {
+	return NewConfluenceWriter(ConfluenceConfig{
+		BaseURL:  url + "/",
+		SpaceKey: "ENG",
+		ParentID: "42",
+		Title:    "Inventory",
+		Token:    "pat",
+	})
+}
+
+func TestConfluenceWriterCreatesMissingPage(t *testing.T) {
+	stub := &confluenceStub{}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	if err := newTestConfluenceWriter(server.URL).Write(testConfluenceInventory()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if len(stub.created) != 1 || len(stub.updated) != 0 {
+		t.Fatalf("got %d created, %d updated pages; want 1 created", len(stub.created), len(stub.updated))
+	}
+	page := stub.created[0]
+	if page.Title != "Inventory" || page.Space == nil || page.Space.Key != "ENG" {
+		t.Errorf("created page %q in %+v, want Inventory in ENG", page.Title, page.Space)
+	}
+	if len(page.Ancestors) != 1 || page.Ancestors[0].ID != "42" {
+		t.Errorf("ancestors = %+v, want parent 42", page.Ancestors)
+	}
+	if page.Version == nil || page.Version.Number != 1 {
+		t.Errorf("version = %+v, want 1", page.Version)
+	}
+	if page.Body == nil || !strings.Contains(page.Body.Storage.Value, "<td>api</td>") {
+		t.Errorf("body doesn't list the repository")
+	}
+}
+
+func TestConfluenceWriterUpdatesExistingPage(t *testing.T) {
+	stub := &confluenceStub{existing: &confluencePage{
+		ID:      "1001",
+		Type:    "page",
+		Title:   "Inventory",
+		Version: &confluenceVersion{Number: 7},
+	}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	if err := newTestConfluenceWriter(server.URL).Write(testConfluenceInventory()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if len(stub.created) != 0 {
+		t.Fatalf("created %d pages, want the existing one updated", len(stub.created))
+	}
+	page, ok := stub.updated["1001"]
+	if !ok {
+		t.Fatalf("page 1001 not updated; updated %v", stub.updated)
+	}
+	if page.Version == nil || page.Version.Number != 8 {
+		t.Errorf("version = %+v, want 8", page.Version)
+	}
+	if page.ID != "1001" {
+		t.Errorf("id = %q, want 1001", page.ID)
+	}
+}
+
+func TestConfluenceWriterReturnsAPIErrors(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusInternalServerError} {
+		server := httptest.NewServer(&confluenceStub{status: status})
+
+		err := newTestConfluenceWriter(server.URL).Write(testConfluenceInventory())
+		if err == nil {
+			t.Errorf("status %d: Write succeeded, want an error", status)
+		} else if !strings.Contains(err.Error(), http.StatusText(status)) {
+			t.Errorf("status %d: error %q doesn't name the status", status, err)
+		}
+		server.Close()
+	}
+}
@@ -0,0 +1,231 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Stdout ---------------------------------------------------------------------------------
+// Writes CSV format to stdout
+type StdoutCSVWriter struct {
+	includeTags bool
+}
+
+// includeTags adds one "tag:<key>" column per AWS tag key
+func NewStdoutCSVWriter(includeTags bool) *StdoutCSVWriter {
+	return &StdoutCSVWriter{includeTags: includeTags}
+}
+
+// Outputs the inventory as CSV to stdout
+func (w *StdoutCSVWriter) Write(inv *inventory.Inventory) error {
+	return writeCSV(os.Stdout, inv, w.includeTags)
+}
+
+// Stdout ---------------------------------------------------------------------------------
+
+// File ------------------------------------------------------------------------------------
+// Writes CSV format to a file
+type FileCSVWriter struct {
+	filepath    string
+	includeTags bool
+}
+
+// includeTags adds one "tag:<key>" column per AWS tag key
+func NewFileCSVWriter(filepath string, includeTags bool) *FileCSVWriter {
+	return &FileCSVWriter{filepath: filepath, includeTags: includeTags}
+}
+
+// Outputs the inventory as CSV to a file
+func (w *FileCSVWriter) Write(inv *inventory.Inventory) error {
+	file, err := os.Create(w.filepath)
+	if err != nil {
+		return fmt.Errorf("fileCSVWriter: failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	return writeCSV(file, inv, w.includeTags)
+}
+
+// File ------------------------------------------------------------------------------------
+
+// Writes the inventory as RFC 4180 CSV (quoted where needed, CRLF line endings) for spreadsheet import
+func writeCSV(writer io.Writer, inv *inventory.Inventory, includeTags bool) error {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.UseCRLF = true // RFC 4180 line endings, which Excel expects
+
+	// A CSV has a single header, so mixed inventories use a combined column set
+	github, aws, other := splitBySource(inv)
+
+	var rows [][]string
+	switch {
+	case len(inv.Services) > 0:
+		rows = serviceRows(inv)
+	case len(other.Resources) > 0:
+		rows = mixedCSVRows(inv, includeTags)
+	case len(aws.Resources) == 0:
+		rows = gitHubCSVRows(github)
+	case len(github.Resources) == 0:
+		rows = awsCSVRows(aws, includeTags)
+	default:
+		rows = mixedCSVRows(inv, includeTags)
+	}
+
+	if err := csvWriter.WriteAll(rows); err != nil {
+		return fmt.Errorf("writeCSV: %w", err)
+	}
+	return nil
+}
+
+// Builds the header and rows for GitHub inventory, same columns as the markdown table
+func gitHubCSVRows(inv *inventory.Inventory) [][]string {
+	rows := [][]string{{"Repo Name", "Owner(s)", "Last Committer", "CODEOWNERS", "Platform", "Environments", "CI/CD", "Tests"}}
+
+	for _, res := range inv.Resources {
+		cicd := res.CICDPlatform
+		if cicd == "" {
+			cicd = "No"
+		}
+
+		owners := "Unknown"
+		if res.HasCodeOwners && len(res.CodeOwners) > 0 {
+			owners = strings.Join(res.CodeOwners, ", ")
+		}
+
+		tests := "No"
+		if res.HasTests {
+			tests = "Yes"
+			if res.TestFramework != "" {
+				tests = fmt.Sprintf("Yes (%s)", res.TestFramework)
+			}
+		}
+
+		rows = append(rows, []string{
+			res.AppName,
+			owners,
+			res.LastCommitter,
+			formatCodeOwnersStatus(res),
+			formatPlatform(res),
+			strings.Join(res.DeployEnvironments, ", "),
+			cicd,
+			tests,
+		})
+	}
+
+	return rows
+}
+
+// Builds the header and rows for AWS inventory, same columns as the markdown table.
+// With includeTags every tag key seen in the inventory becomes a sorted "tag:<key>" column.
+func awsCSVRows(inv *inventory.Inventory, includeTags bool) [][]string {
+	header := []string{"App Name", "Owner", "Team", "Platform", "Stack Name", "CI/CD", "Account", "Region"}
+
+	var tagKeys []string
+	if includeTags {
+		tagKeys = collectTagKeys(inv)
+		for _, key := range tagKeys {
+			header = append(header, "tag:"+key)
+		}
+	}
+
+	rows := [][]string{header}
+	for _, res := range inv.Resources {
+		row := []string{
+			res.AppName,
+			res.Owner,
+			res.Team,
+			res.Platform,
+			res.StackName,
+			formatBool(res.HasCICD),
+			res.Account,
+			res.Region,
+		}
+		for _, key := range tagKeys {
+			row = append(row, res.ResourceTags[key]) // missing tag -> empty cell
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Builds the header and rows for an inventory holding several sources.
+// Columns are the union of the GitHub and AWS layouts with a leading Source column; cells that don't apply are empty.
+func mixedCSVRows(inv *inventory.Inventory, includeTags bool) [][]string {
+	header := []string{"Source", "Name", "Owner", "Team", "Platform", "CI/CD", "Tests", "Last Committer", "Stack Name", "Account", "Region"}
+
+	var tagKeys []string
+	if includeTags {
+		tagKeys = collectTagKeys(inv)
+		for _, key := range tagKeys {
+			header = append(header, "tag:"+key)
+		}
+	}
+
+	rows := [][]string{header}
+	for _, res := range inv.Resources {
+		cicd := res.CICDPlatform
+		if cicd == "" {
+			cicd = formatBool(res.HasCICD)
+		}
+
+		tests := ""
+		if res.GitHubRepo != "" {
+			tests = formatBool(res.HasTests)
+			if res.HasTests && res.TestFramework != "" {
+				tests = fmt.Sprintf("Yes (%s)", res.TestFramework)
+			}
+		}
+
+		owner := res.Owner
+		if res.HasCodeOwners && len(res.CodeOwners) > 0 {
+			owner = strings.Join(res.CodeOwners, ", ")
+		}
+
+		stack := res.StackName
+		if res.GitHubRepo != "" {
+			stack = ""
+		}
+
+		row := []string{
+			res.Source,
+			res.AppName,
+			owner,
+			res.Team,
+			res.Platform,
+			cicd,
+			tests,
+			res.LastCommitter,
+			stack,
+			res.Account,
+			res.Region,
+		}
+		for _, key := range tagKeys {
+			row = append(row, res.ResourceTags[key])
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Returns the union of tag keys across all resources, sorted
+func collectTagKeys(inv *inventory.Inventory) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, res := range inv.Resources {
+		for key := range res.ResourceTags {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
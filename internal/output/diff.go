@@ -0,0 +1,185 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+	"github.com/ervinmplayon/tractatus/internal/snapshot"
+)
+
+// Formats the diff command can write
+var DiffFormats = []string{"table", "markdown", "json"}
+
+// Writes a snapshot diff as a table, markdown or JSON, to stdout or a file
+type DiffWriter struct {
+	format   string
+	filepath string
+}
+
+// Returns a diff writer; filepath "stdout" writes to stdout
+func NewDiffWriter(format, filepath string) *DiffWriter {
+	return &DiffWriter{format: format, filepath: filepath}
+}
+
+// Outputs the diff in the writer's format
+func (w *DiffWriter) Write(diff *snapshot.Diff) error {
+	var writer io.Writer = os.Stdout
+	if w.filepath != "stdout" {
+		file, err := os.Create(w.filepath)
+		if err != nil {
+			return fmt.Errorf("diffWriter: failed to create file: %w", err)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	switch w.format {
+	case "table":
+		return writeDiffTable(writer, diff)
+	case "markdown":
+		return writeDiffMarkdown(writer, diff)
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			return fmt.Errorf("diffWriter: failed to encode diff: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("diffWriter: unknown format '%s'", w.format)
+}
+
+// Writes the diff as formatted tables: added, removed, then changed fields
+func writeDiffTable(writer io.Writer, diff *snapshot.Diff) error {
+	fmt.Fprintf(writer, "Changes from %s to %s\n", formatRef(diff.From), formatRef(diff.To))
+	fmt.Fprintf(writer, "Added: %d, Removed: %d, Changed: %d\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	if diff.Empty() {
+		fmt.Fprintln(writer, "\nNo changes.")
+		return nil
+	}
+
+	sections := diffSections(diff)
+	for _, section := range sections {
+		if section.count == 0 {
+			continue
+		}
+		fmt.Fprintf(writer, "\n%s (%d)\n\n", section.title, section.count)
+		widths := calculateColumnWidths(section.rows)
+		printTableRow(writer, widths, section.rows[0]...)
+		printTableSeparator(writer, widths)
+		for _, row := range section.rows[1:] {
+			printTableRow(writer, widths, row...)
+		}
+	}
+	return nil
+}
+
+// Writes the diff as markdown
+func writeDiffMarkdown(writer io.Writer, diff *snapshot.Diff) error {
+	fmt.Fprintln(writer, "# Inventory Changes")
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "From `%s` to `%s`\n", formatRef(diff.From), formatRef(diff.To))
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "## Summary")
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "- **Added**: %d\n", len(diff.Added))
+	fmt.Fprintf(writer, "- **Removed**: %d\n", len(diff.Removed))
+	fmt.Fprintf(writer, "- **Changed**: %d\n", len(diff.Changed))
+
+	sections := diffSections(diff)
+	for _, section := range sections {
+		if section.count == 0 {
+			continue
+		}
+		fmt.Fprintln(writer)
+		fmt.Fprintf(writer, "## %s\n", section.title)
+		fmt.Fprintln(writer)
+		fmt.Fprintf(writer, "| %s |\n", strings.Join(section.rows[0], " | "))
+		fmt.Fprintf(writer, "|%s\n", strings.Repeat("---|", len(section.rows[0])))
+		for _, row := range section.rows[1:] {
+			escaped := make([]string, len(row))
+			for i, cell := range row {
+				escaped[i] = escapeMarkdown(cell)
+			}
+			fmt.Fprintf(writer, "| %s |\n", strings.Join(escaped, " | "))
+		}
+	}
+	return nil
+}
+
+// Added, removed and changed resources with their rows (header first)
+type diffSection struct {
+	title string
+	count int
+	rows  [][]string
+}
+
+func diffSections(diff *snapshot.Diff) []diffSection {
+	return []diffSection{
+		{"Added", len(diff.Added), diffResourceRows(diff.Added)},
+		{"Removed", len(diff.Removed), diffResourceRows(diff.Removed)},
+		{"Changed", len(diff.Changed), diffChangeRows(diff.Changed)},
+	}
+}
+
+// Builds the header and one row per added or removed resource
+func diffResourceRows(resources []*inventory.ResourceInfo) [][]string {
+	rows := [][]string{{"Source", "Name", "Owner", "Platform", "CI/CD", "Tests", "Location"}}
+	for _, res := range resources {
+		cicd := res.CICDPlatform
+		if cicd == "" {
+			cicd = formatBool(res.HasCICD)
+		}
+
+		location := res.RepoURL
+		if res.GitHubRepo == "" {
+			location = fmt.Sprintf("%s/%s", res.Account, res.Region)
+		}
+
+		rows = append(rows, []string{
+			snapshot.SourceOf(res),
+			res.AppName,
+			res.Owner,
+			res.Platform,
+			cicd,
+			formatBool(res.HasTests),
+			location,
+		})
+	}
+	return rows
+}
+
+// Builds the header and one row per changed field
+func diffChangeRows(changes []snapshot.ResourceChange) [][]string {
+	rows := [][]string{{"Source", "Name", "Field", "Change", "Before", "After"}}
+	for _, change := range changes {
+		for _, field := range change.Changes {
+			rows = append(rows, []string{
+				change.Source,
+				change.Name,
+				field.Field,
+				field.Kind,
+				orDash(field.Old),
+				orDash(field.New),
+			})
+		}
+	}
+	return rows
+}
+
+// Describes one side of a diff as "<id> (<time>, <sources>)"
+func formatRef(ref snapshot.Ref) string {
+	return fmt.Sprintf("%s (%s, %s)", ref.ID, ref.TakenAt.Format(time.RFC3339), strings.Join(ref.Sources, ", "))
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,401 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ervinmplayon/tractatus/internal/snapshot"
+)
+
+// Formats the history command can write
+var HistoryFormats = []string{"markdown", "csv", "svg"}
+
+// Summary metrics of one saved snapshot
+type HistoryPoint struct {
+	ID      string
+	TakenAt time.Time
+	GitHub  Summary
+	AWS     Summary
+}
+
+// Computes the GitHub and AWS summaries of each snapshot, in the snapshots' order
+func BuildHistory(snaps []*snapshot.Snapshot) []HistoryPoint {
+	points := make([]HistoryPoint, 0, len(snaps))
+	for _, snap := range snaps {
+		github, aws, _ := splitBySource(snap.Inventory)
+		points = append(points, HistoryPoint{
+			ID:      snap.ID,
+			TakenAt: snap.TakenAt,
+			GitHub:  generateGitHubSummary(github),
+			AWS:     generateAWSSummary(aws),
+		})
+	}
+	return points
+}
+
+// Writes a history as markdown, CSV or a standalone SVG chart, to stdout or a file
+type HistoryWriter struct {
+	format   string
+	filepath string
+}
+
+// Returns a history writer; filepath "stdout" writes to stdout
+func NewHistoryWriter(format, filepath string) *HistoryWriter {
+	return &HistoryWriter{format: format, filepath: filepath}
+}
+
+// Outputs the history in the writer's format
+func (w *HistoryWriter) Write(points []HistoryPoint) error {
+	var writer io.Writer = os.Stdout
+	if w.filepath != "stdout" {
+		file, err := os.Create(w.filepath)
+		if err != nil {
+			return fmt.Errorf("historyWriter: failed to create file: %w", err)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	switch w.format {
+	case "markdown":
+		// GitHub and Confluence strip data-URI images, so the chart goes in a file next to the report
+		chartFile := ""
+		if w.filepath != "stdout" {
+			chartPath := historyChartPath(w.filepath)
+			if err := os.WriteFile(chartPath, []byte(historyChart(points)), 0o644); err != nil {
+				return fmt.Errorf("historyWriter: failed to write chart: %w", err)
+			}
+			chartFile = filepath.Base(chartPath)
+		}
+		return writeHistoryMarkdown(writer, points, chartFile)
+	case "csv":
+		return writeHistoryCSV(writer, points)
+	case "svg":
+		_, err := io.WriteString(writer, historyChart(points))
+		return err
+	}
+	return fmt.Errorf("historyWriter: unknown format '%s'", w.format)
+}
+
+// Returns where the markdown report's chart is written: history.md gets history.svg
+func historyChartPath(reportPath string) string {
+	chartPath := strings.TrimSuffix(reportPath, filepath.Ext(reportPath)) + ".svg"
+	if chartPath == reportPath {
+		chartPath += ".svg"
+	}
+	return chartPath
+}
+
+// Writes the coverage chart link and one table per metric group, a row per snapshot.
+// chartFile is the chart's path relative to the report, "" when there is no file to link (stdout).
+func writeHistoryMarkdown(writer io.Writer, points []HistoryPoint, chartFile string) error {
+	fmt.Fprintln(writer, "# Inventory History")
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "**Generated**: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(writer)
+	if len(points) == 0 {
+		fmt.Fprintln(writer, "No snapshots found.")
+		return nil
+	}
+	fmt.Fprintf(writer, "%d snapshots from %s to %s\n", len(points),
+		points[0].TakenAt.Format("2006-01-02"), points[len(points)-1].TakenAt.Format("2006-01-02"))
+	fmt.Fprintln(writer)
+
+	if chartFile != "" {
+		fmt.Fprintf(writer, "![Coverage over time](%s)\n", chartFile)
+	} else {
+		fmt.Fprintln(writer, "_Coverage chart: write the report to a file with --output, or run with --format svg._")
+	}
+
+	hasGitHub, hasAWS := historySources(points)
+	if hasGitHub {
+		rows := [][]string{{"Date", "Snapshot", "Repositories", "With CI/CD", "With Tests", "With CODEOWNERS", "With CODEOWNERS errors"}}
+		for _, point := range points {
+			summary := point.GitHub
+			invalid := "-" // not validated in that run
+			if summary.CheckedCodeOwners > 0 {
+				invalid = formatShare(summary.InvalidCodeOwners, summary.TotalResources)
+			}
+			rows = append(rows, []string{
+				point.TakenAt.Format("2006-01-02 15:04"),
+				point.ID,
+				fmt.Sprint(summary.TotalResources),
+				formatShare(summary.WithCICD, summary.TotalResources),
+				formatShare(summary.WithTests, summary.TotalResources),
+				formatShare(summary.WithCodeOwners, summary.TotalResources),
+				invalid,
+			})
+		}
+		writeMarkdownRows(writer, "GitHub Coverage", rows)
+		writeMarkdownRows(writer, "GitHub Platforms", breakdownRows(points, func(point HistoryPoint) map[string]int { return point.GitHub.ByPlatform }))
+	}
+	if hasAWS {
+		rows := [][]string{{"Date", "Snapshot", "Resources", "With CI/CD"}}
+		for _, point := range points {
+			summary := point.AWS
+			rows = append(rows, []string{
+				point.TakenAt.Format("2006-01-02 15:04"),
+				point.ID,
+				fmt.Sprint(summary.TotalResources),
+				formatShare(summary.WithCICD, summary.TotalResources),
+			})
+		}
+		writeMarkdownRows(writer, "AWS Coverage", rows)
+		writeMarkdownRows(writer, "AWS Platforms", breakdownRows(points, func(point HistoryPoint) map[string]int { return point.AWS.ByPlatform }))
+		writeMarkdownRows(writer, "AWS Accounts", breakdownRows(points, func(point HistoryPoint) map[string]int { return point.AWS.ByAccount }))
+	}
+	return nil
+}
+
+// Writes a titled markdown table, header row first
+func writeMarkdownRows(writer io.Writer, title string, rows [][]string) {
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "## %s\n", title)
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "| %s |\n", strings.Join(rows[0], " | "))
+	fmt.Fprintf(writer, "|%s\n", strings.Repeat("---|", len(rows[0])))
+	for _, row := range rows[1:] {
+		escaped := make([]string, len(row))
+		for i, cell := range row {
+			escaped[i] = escapeMarkdown(cell)
+		}
+		fmt.Fprintf(writer, "| %s |\n", strings.Join(escaped, " | "))
+	}
+}
+
+// Builds a date column plus one count column per key seen in any snapshot
+func breakdownRows(points []HistoryPoint, counts func(point HistoryPoint) map[string]int) [][]string {
+	keys := breakdownKeys(points, counts)
+	header := []string{"Date"}
+	for _, key := range keys {
+		header = append(header, orNone(key))
+	}
+
+	rows := [][]string{header}
+	for _, point := range points {
+		row := []string{point.TakenAt.Format("2006-01-02 15:04")}
+		for _, key := range keys {
+			row = append(row, fmt.Sprint(counts(point)[key]))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Returns the sorted union of the keys of every snapshot's counts
+func breakdownKeys(points []HistoryPoint, counts func(point HistoryPoint) map[string]int) []string {
+	union := make(map[string]int)
+	for _, point := range points {
+		for key := range counts(point) {
+			union[key]++
+		}
+	}
+	return sortedKeys(union)
+}
+
+// Writes one CSV row per snapshot with every metric as its own column
+func writeHistoryCSV(writer io.Writer, points []HistoryPoint) error {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.UseCRLF = true // RFC 4180 line endings, which Excel expects
+
+	githubPlatforms := breakdownKeys(points, func(point HistoryPoint) map[string]int { return point.GitHub.ByPlatform })
+	awsPlatforms := breakdownKeys(points, func(point HistoryPoint) map[string]int { return point.AWS.ByPlatform })
+	accounts := breakdownKeys(points, func(point HistoryPoint) map[string]int { return point.AWS.ByAccount })
+
+	header := []string{
+		"Snapshot", "Taken At",
+		"GitHub Repositories", "GitHub With CI/CD", "GitHub With Tests", "GitHub With CODEOWNERS", "GitHub With CODEOWNERS Errors",
+		"AWS Resources", "AWS With CI/CD",
+	}
+	for _, platform := range githubPlatforms {
+		header = append(header, "GitHub Platform: "+orNone(platform))
+	}
+	for _, platform := range awsPlatforms {
+		header = append(header, "AWS Platform: "+orNone(platform))
+	}
+	for _, account := range accounts {
+		header = append(header, "Account: "+orNone(account))
+	}
+
+	rows := [][]string{header}
+	for _, point := range points {
+		invalid := "" // not validated in that run
+		if point.GitHub.CheckedCodeOwners > 0 {
+			invalid = fmt.Sprint(point.GitHub.InvalidCodeOwners)
+		}
+		row := []string{
+			point.ID,
+			point.TakenAt.Format(time.RFC3339),
+			fmt.Sprint(point.GitHub.TotalResources),
+			fmt.Sprint(point.GitHub.WithCICD),
+			fmt.Sprint(point.GitHub.WithTests),
+			fmt.Sprint(point.GitHub.WithCodeOwners),
+			invalid,
+			fmt.Sprint(point.AWS.TotalResources),
+			fmt.Sprint(point.AWS.WithCICD),
+		}
+		for _, platform := range githubPlatforms {
+			row = append(row, fmt.Sprint(point.GitHub.ByPlatform[platform]))
+		}
+		for _, platform := range awsPlatforms {
+			row = append(row, fmt.Sprint(point.AWS.ByPlatform[platform]))
+		}
+		for _, account := range accounts {
+			row = append(row, fmt.Sprint(point.AWS.ByAccount[account]))
+		}
+		rows = append(rows, row)
+	}
+
+	if err := csvWriter.WriteAll(rows); err != nil {
+		return fmt.Errorf("writeHistoryCSV: %w", err)
+	}
+	return nil
+}
+
+// Chart layout, in SVG user units
+const (
+	chartWidth  = 720
+	chartHeight = 320
+	chartLeft   = 50  // room for the % axis
+	chartRight  = 190 // room for the legend
+	chartTop    = 20
+	chartBottom = 50 // room for the date labels
+	maxXLabels  = 8
+)
+
+// One line of the coverage chart: a share per snapshot, or -1 where the source wasn't collected
+type chartSeries struct {
+	name   string
+	color  string
+	values []float64
+}
+
+// Draws the coverage percentages over time as a small line chart
+func historyChart(points []HistoryPoint) string {
+	hasGitHub, hasAWS := historySources(points)
+	var series []chartSeries
+	if hasGitHub {
+		series = append(series,
+			coverageSeries(points, "GitHub CI/CD", "#1f77b4", func(s Summary) int { return s.WithCICD }, true),
+			coverageSeries(points, "GitHub tests", "#2ca02c", func(s Summary) int { return s.WithTests }, true),
+			coverageSeries(points, "GitHub CODEOWNERS", "#ff7f0e", func(s Summary) int { return s.WithCodeOwners }, true),
+		)
+	}
+	if hasAWS {
+		series = append(series, coverageSeries(points, "AWS CI/CD", "#9467bd", func(s Summary) int { return s.WithCICD }, false))
+	}
+
+	plotWidth := float64(chartWidth - chartLeft - chartRight)
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+	x := func(i int) float64 {
+		if len(points) == 1 {
+			return chartLeft + plotWidth/2
+		}
+		return chartLeft + plotWidth*float64(i)/float64(len(points)-1)
+	}
+	y := func(share float64) float64 {
+		return chartTop + plotHeight*(1-share)
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", chartWidth, chartHeight)
+
+	// Horizontal grid every 25%
+	for pct := 0; pct <= 100; pct += 25 {
+		lineY := y(float64(pct) / 100)
+		fmt.Fprintf(&svg, `<line x1="%d" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#dddddd"/>`+"\n", chartLeft, lineY, chartLeft+plotWidth, lineY)
+		fmt.Fprintf(&svg, `<text x="%d" y="%.1f" text-anchor="end" dominant-baseline="middle">%d%%</text>`+"\n", chartLeft-6, lineY, pct)
+	}
+
+	// Date labels, thinned out so they don't overlap
+	step := (len(points) + maxXLabels - 1) / maxXLabels
+	for i, point := range points {
+		if step > 1 && i%step != 0 && i != len(points)-1 {
+			continue
+		}
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`+"\n",
+			x(i), chartTop+plotHeight+18, point.TakenAt.Format("2006-01-02"))
+	}
+
+	for i, line := range series {
+		// Break the line where the source wasn't collected
+		var segment []string
+		flush := func() {
+			if len(segment) > 1 {
+				fmt.Fprintf(&svg, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`+"\n", line.color, strings.Join(segment, " "))
+			}
+			segment = nil
+		}
+		for j, share := range line.values {
+			if share < 0 {
+				flush()
+				continue
+			}
+			segment = append(segment, fmt.Sprintf("%.1f,%.1f", x(j), y(share)))
+		}
+		flush()
+
+		// Markers on top of the line, with the value as a tooltip
+		for j, share := range line.values {
+			if share >= 0 {
+				fmt.Fprintf(&svg, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s %s: %.0f%%</title></circle>`+"\n",
+					x(j), y(share), line.color, line.name, points[j].TakenAt.Format("2006-01-02"), share*100)
+			}
+		}
+
+		legendY := chartTop + 10 + 18*i
+		fmt.Fprintf(&svg, `<rect x="%.1f" y="%d" width="12" height="12" fill="%s"/>`+"\n", chartLeft+plotWidth+20, legendY-6, line.color)
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%d" dominant-baseline="middle">%s</text>`+"\n", chartLeft+plotWidth+38, legendY, line.name)
+	}
+
+	svg.WriteString("</svg>\n")
+	return svg.String()
+}
+
+// Builds a coverage line from one Summary count over the source's total
+func coverageSeries(points []HistoryPoint, name, color string, count func(Summary) int, github bool) chartSeries {
+	line := chartSeries{name: name, color: color}
+	for _, point := range points {
+		summary := point.AWS
+		if github {
+			summary = point.GitHub
+		}
+		share := -1.0
+		if summary.TotalResources > 0 {
+			share = float64(count(summary)) / float64(summary.TotalResources)
+		}
+		line.values = append(line.values, share)
+	}
+	return line
+}
+
+// Reports which sources appear in any snapshot
+func historySources(points []HistoryPoint) (github, aws bool) {
+	for _, point := range points {
+		github = github || point.GitHub.TotalResources > 0
+		aws = aws || point.AWS.TotalResources > 0
+	}
+	return github, aws
+}
+
+// Formats a count with its share of the total, e.g. "12 (60%)"
+func formatShare(count, total int) string {
+	if total == 0 {
+		return fmt.Sprint(count)
+	}
+	return fmt.Sprintf("%d (%.0f%%)", count, float64(count)*100/float64(total))
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "None"
+	}
+	return value
+}
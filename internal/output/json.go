@@ -0,0 +1,151 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Version of the JSON and NDJSON output schema.
+// Adding fields is backwards compatible and keeps the version; renaming or removing a field bumps it.
+//
+// JSON:   {"schema_version": 2, "generated_at": "<RFC 3339>", "options": {...}, "resource_count": N, "resources": [<resource>, ...], "services": [...]}
+// "services" only appears with --correlate, see inventory.Service; they refer to resources by ResourceInfo.Key().
+// "options" holds the collection settings, as saved with snapshots, so the file can serve as a --baseline.
+// NDJSON: one {"schema_version": 2, <resource fields>} object per line, nothing else
+//
+// A <resource> carries every inventory.ResourceInfo field under its json tag. Every field is always present;
+// fields that don't apply to the resource's source are empty ("", false, null).
+const SchemaVersion = 2
+
+// Top-level JSON document
+type jsonDocument struct {
+	SchemaVersion int                       `json:"schema_version"`
+	GeneratedAt   string                    `json:"generated_at"`
+	Options       map[string]string         `json:"options,omitempty"`
+	ResourceCount int                       `json:"resource_count"`
+	Resources     []*inventory.ResourceInfo `json:"resources"`
+	Services      []*inventory.Service      `json:"services,omitempty"`
+	Skipped       []inventory.Skipped       `json:"skipped,omitempty"`
+}
+
+// Single NDJSON line: the resource's fields with the schema version alongside
+type ndjsonRecord struct {
+	SchemaVersion int `json:"schema_version"`
+	*inventory.ResourceInfo
+}
+
+// Stdout ---------------------------------------------------------------------------------
+// Writes JSON format to stdout
+type StdoutJSONWriter struct {
+	options map[string]string
+}
+
+// options are the collection settings recorded in the document, nil for none
+func NewStdoutJSONWriter(options map[string]string) *StdoutJSONWriter {
+	return &StdoutJSONWriter{options: options}
+}
+
+// Outputs the inventory as a JSON document to stdout
+func (w *StdoutJSONWriter) Write(inv *inventory.Inventory) error {
+	return writeJSON(os.Stdout, inv, w.options)
+}
+
+// Writes NDJSON format to stdout
+type StdoutNDJSONWriter struct{}
+
+func NewStdoutNDJSONWriter() *StdoutNDJSONWriter {
+	return &StdoutNDJSONWriter{}
+}
+
+// Outputs the inventory as one JSON object per line to stdout
+func (w *StdoutNDJSONWriter) Write(inv *inventory.Inventory) error {
+	return writeNDJSON(os.Stdout, inv)
+}
+
+// Stdout ---------------------------------------------------------------------------------
+
+// File ------------------------------------------------------------------------------------
+// Writes JSON format to a file
+type FileJSONWriter struct {
+	filepath string
+	options  map[string]string
+}
+
+// options are the collection settings recorded in the document, nil for none
+func NewFileJSONWriter(filepath string, options map[string]string) *FileJSONWriter {
+	return &FileJSONWriter{filepath: filepath, options: options}
+}
+
+// Outputs the inventory as a JSON document to a file
+func (w *FileJSONWriter) Write(inv *inventory.Inventory) error {
+	file, err := os.Create(w.filepath)
+	if err != nil {
+		return fmt.Errorf("fileJSONWriter: failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	return writeJSON(file, inv, w.options)
+}
+
+// Writes NDJSON format to a file
+type FileNDJSONWriter struct {
+	filepath string
+}
+
+func NewFileNDJSONWriter(filepath string) *FileNDJSONWriter {
+	return &FileNDJSONWriter{filepath: filepath}
+}
+
+// Outputs the inventory as one JSON object per line to a file
+func (w *FileNDJSONWriter) Write(inv *inventory.Inventory) error {
+	file, err := os.Create(w.filepath)
+	if err != nil {
+		return fmt.Errorf("fileNDJSONWriter: failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	return writeNDJSON(file, inv)
+}
+
+// File ------------------------------------------------------------------------------------
+
+// Writes the whole inventory as a single indented JSON document
+func writeJSON(writer io.Writer, inv *inventory.Inventory, options map[string]string) error {
+	resources := inv.Resources
+	if resources == nil {
+		resources = []*inventory.ResourceInfo{} // "resources": [] rather than null
+	}
+
+	doc := jsonDocument{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		Options:       options,
+		ResourceCount: len(resources),
+		Resources:     resources,
+		Services:      inv.Services,
+		Skipped:       inv.Skipped,
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("writeJSON: failed to encode inventory: %w", err)
+	}
+	return nil
+}
+
+// Writes one resource per line so consumers can stream the output
+func writeNDJSON(writer io.Writer, inv *inventory.Inventory) error {
+	encoder := json.NewEncoder(writer) // Encode terminates each value with a newline
+	for _, res := range inv.Resources {
+		if err := encoder.Encode(ndjsonRecord{SchemaVersion: SchemaVersion, ResourceInfo: res}); err != nil {
+			return fmt.Errorf("writeNDJSON: failed to encode %s: %w", res.AppName, err)
+		}
+	}
+	return nil
+}
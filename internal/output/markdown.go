@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -56,27 +57,78 @@ func writeMarkdown(writer io.Writer, inv *inventory.Inventory) error {
 	fmt.Fprintf(writer, "**Generated**: %s\n", time.Now().Format("2006-01-02 15:04:05"))
 	fmt.Fprintln(writer)
 
+	if err := writeMarkdownSections(writer, inv); err != nil {
+		return err
+	}
+	writeSkippedMarkdown(writer, inv.Skipped)
+	return nil
+}
+
+// Writes the inventory sections for whichever sources are present
+func writeMarkdownSections(writer io.Writer, inv *inventory.Inventory) error {
 	if len(inv.Resources) == 0 {
 		fmt.Fprintln(writer, "No resources found.")
 		return nil
 	}
 
-	// Detect if this is GitHub or AWS data
-	isGitHub := len(inv.Resources) > 0 && inv.Resources[0].GitHubRepo != ""
+	// Correlated run: one row per service
+	if len(inv.Services) > 0 {
+		return writeServicesMarkdown(writer, inv)
+	}
+
+	// Single-source runs use top-level sections; mixed runs nest each source under its own heading
+	github, aws, other := splitBySource(inv)
+	warnOtherSources(other, "markdown")
+	switch {
+	case len(github.Resources) == 0 && len(aws.Resources) == 0:
+		fmt.Fprintln(writer, "No GitHub or AWS resources found.")
+		return nil
+	case len(aws.Resources) == 0:
+		return writeGitHubMarkdown(writer, github, "##")
+	case len(github.Resources) == 0:
+		return writeAWSMarkdown(writer, aws, "##")
+	}
+
+	fmt.Fprintln(writer, "## GitHub")
+	fmt.Fprintln(writer)
+	if err := writeGitHubMarkdown(writer, github, "###"); err != nil {
+		return err
+	}
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "## AWS")
+	fmt.Fprintln(writer)
+	return writeAWSMarkdown(writer, aws, "###")
+}
+
+// Writes what the sources left out, so excluded workloads aren't silently missing
+func writeSkippedMarkdown(writer io.Writer, skipped []inventory.Skipped) {
+	if len(skipped) == 0 {
+		return
+	}
 
-	if isGitHub {
-		return writeGitHubMarkdown(writer, inv)
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "## Skipped")
+	fmt.Fprintln(writer)
+	groups := groupSkipped(skipped)
+	for _, group := range groups {
+		fmt.Fprintf(writer, "- **%s, %s**: %d (%s)\n", group.Source, group.Reason, len(group.Names), escapeMarkdown(formatSkippedNames(group.Names)))
+	}
+	if hasEKSSkips(groups) {
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "EKS workloads are included with `--include-eks`.")
 	}
-	return writeAWSMarkdown(writer, inv)
 }
 
-// Writes GitHub inventory as markdown
-func writeGitHubMarkdown(writer io.Writer, inv *inventory.Inventory) error {
+// Writes GitHub inventory as markdown, with section headings at the given level ("##", "###")
+func writeGitHubMarkdown(writer io.Writer, inv *inventory.Inventory, heading string) error {
 	// Summary statistics
 	summary := generateGitHubSummary(inv)
-	fmt.Fprintln(writer, "## Summary")
+	fmt.Fprintf(writer, "%s Summary\n", heading)
 	fmt.Fprintln(writer)
 	fmt.Fprintf(writer, "- **Total Repositories**: %d\n", summary.TotalResources)
+	if summary.DeployableUnits > 0 {
+		fmt.Fprintf(writer, "- **Deployable Units**: %d\n", summary.DeployableUnits)
+	}
 
 	for platform, count := range summary.ByPlatform {
 		fmt.Fprintf(writer, "- **%s**: %d\n", platform, count)
@@ -86,13 +138,16 @@ func writeGitHubMarkdown(writer io.Writer, inv *inventory.Inventory) error {
 	fmt.Fprintf(writer, "- **With CI/CD**: %d\n", summary.WithCICD)
 	fmt.Fprintf(writer, "- **With Tests**: %d\n", summary.WithTests)
 	fmt.Fprintf(writer, "- **With CODEOWNERS**: %d\n", summary.WithCodeOwners)
+	if summary.CheckedCodeOwners > 0 {
+		fmt.Fprintf(writer, "- **With CODEOWNERS errors**: %d\n", summary.InvalidCodeOwners)
+	}
 	fmt.Fprintln(writer)
 
 	// Resources table
-	fmt.Fprintln(writer, "## Repositories")
+	fmt.Fprintf(writer, "%s Repositories\n", heading)
 	fmt.Fprintln(writer)
-	fmt.Fprintln(writer, "| Repo Name | Owner(s) | Last Committer | Platform | CI/CD | Tests |")
-	fmt.Fprintln(writer, "|-----------|----------|----------------|----------|-------|-------|")
+	fmt.Fprintln(writer, "| Repo Name | Owner(s) | Last Committer | CODEOWNERS | Platform | Environments | CI/CD | Tests |")
+	fmt.Fprintln(writer, "|-----------|----------|----------------|------------|----------|--------------|-------|-------|")
 
 	for _, res := range inv.Resources {
 		cicd := res.CICDPlatform
@@ -115,24 +170,216 @@ func writeGitHubMarkdown(writer io.Writer, inv *inventory.Inventory) error {
 			}
 		}
 
-		fmt.Fprintf(writer, "| %s | %s | %s | %s | %s | %s |\n",
+		fmt.Fprintf(writer, "| %s | %s | %s | %s | %s | %s | %s | %s |\n",
 			escapeMarkdown(res.AppName),
 			escapeMarkdown(owners),
 			escapeMarkdown(res.LastCommitter),
-			escapeMarkdown(res.Platform),
+			formatCodeOwnersStatus(res),
+			escapeMarkdown(formatPlatform(res)),
+			escapeMarkdown(strings.Join(res.DeployEnvironments, ", ")),
 			escapeMarkdown(cicd),
 			escapeMarkdown(tests),
 		)
 	}
 
+	writeCICDMarkdown(writer, inv, heading)
+	writeOwnershipMarkdown(writer, inv, heading)
+	writeCodeOwnersErrorsMarkdown(writer, inv, heading)
+	writeOwnerResolutionMarkdown(writer, inv, heading)
+
 	return nil
 }
 
-// Writes AWS inventory as markdown
-func writeAWSMarkdown(writer io.Writer, inv *inventory.Inventory) error {
+// Writes the repos configured for more than one CI/CD system, with the paths each was detected from
+func writeCICDMarkdown(writer io.Writer, inv *inventory.Inventory, heading string) {
+	var repos []*inventory.ResourceInfo
+	for _, res := range inv.Resources {
+		if len(res.CICDPlatforms) > 1 && res.UnitPath == "" { // units share their repo's CI
+			repos = append(repos, res)
+		}
+	}
+	if len(repos) == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "%s Multiple CI/CD Systems\n", heading)
+	fmt.Fprintln(writer)
+
+	for _, res := range repos {
+		fmt.Fprintf(writer, "%s# %s\n", heading, escapeMarkdown(res.AppName))
+		fmt.Fprintln(writer)
+		for _, system := range res.CICDPlatforms {
+			fmt.Fprintf(writer, "- **%s**: `%s`\n", system, strings.Join(res.CICDEvidence[system], "`, `"))
+		}
+		fmt.Fprintln(writer)
+	}
+}
+
+// Writes per-repo CODEOWNERS resolution: default owners, owners of each deployable subdirectory, unowned paths
+func writeOwnershipMarkdown(writer io.Writer, inv *inventory.Inventory, heading string) {
+	var repos []*inventory.ResourceInfo
+	for _, res := range inv.Resources {
+		if len(res.PathOwners) > 0 || len(res.UnownedPaths) > 0 {
+			repos = append(repos, res)
+		}
+	}
+	if len(repos) == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "%s Ownership\n", heading)
+	fmt.Fprintln(writer)
+
+	for _, res := range repos {
+		fmt.Fprintf(writer, "%s# %s\n", heading, escapeMarkdown(res.AppName))
+		fmt.Fprintln(writer)
+
+		fmt.Fprintf(writer, "- **Default owners (`*`)**: %s\n", formatOwnerList(res.RootOwners))
+		for _, dir := range sortedOwnerPaths(res.PathOwners) {
+			fmt.Fprintf(writer, "- **`%s`**: %s\n", dir, formatOwnerList(res.PathOwners[dir]))
+		}
+		if len(res.UnownedPaths) > 0 {
+			fmt.Fprintf(writer, "- **Unowned paths**: `%s`\n", strings.Join(res.UnownedPaths, "`, `"))
+		}
+		fmt.Fprintln(writer)
+	}
+}
+
+// Writes the CODEOWNERS problems GitHub reported, one table per repo.
+// GitHub ignores lines it can't parse and owners without write access, so these rules do nothing today.
+func writeCodeOwnersErrorsMarkdown(writer io.Writer, inv *inventory.Inventory, heading string) {
+	var repos []*inventory.ResourceInfo
+	for _, res := range inv.Resources {
+		if len(res.CodeOwnersErrors) > 0 {
+			repos = append(repos, res)
+		}
+	}
+	if len(repos) == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "%s CODEOWNERS Errors\n", heading)
+	fmt.Fprintln(writer)
+
+	for _, res := range repos {
+		fmt.Fprintf(writer, "%s# %s\n", heading, escapeMarkdown(res.AppName))
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "| File | Line | Kind | Source | Message |")
+		fmt.Fprintln(writer, "|------|------|------|--------|---------|")
+
+		for _, e := range res.CodeOwnersErrors {
+			message := e.Message
+			if e.Suggestion != "" {
+				message = fmt.Sprintf("%s (suggestion: %s)", message, e.Suggestion)
+			}
+			fmt.Fprintf(writer, "| %s | %d | %s | `%s` | %s |\n",
+				escapeMarkdown(e.Path),
+				e.Line,
+				escapeMarkdown(e.Kind),
+				escapeMarkdown(strings.TrimSpace(e.Source)),
+				escapeMarkdown(strings.Join(strings.Fields(message), " ")), // messages span several lines
+			)
+		}
+		fmt.Fprintln(writer)
+	}
+}
+
+// Writes CODEOWNERS entries that didn't resolve to a current org member or a populated team.
+// Only present when the inventory was collected with --resolve-owners.
+func writeOwnerResolutionMarkdown(writer io.Writer, inv *inventory.Inventory, heading string) {
+	resolved := 0
+	byStatus := make(map[string]int)
+	type staleOwner struct {
+		repo   string
+		detail inventory.OwnerDetail
+	}
+	var stale []staleOwner
+
+	for _, res := range inv.Resources {
+		for _, detail := range res.OwnerDetails {
+			resolved++
+			byStatus[detail.Status]++
+			if detail.Status != inventory.OwnerOK {
+				stale = append(stale, staleOwner{repo: res.AppName, detail: detail})
+			}
+		}
+	}
+	if resolved == 0 {
+		return
+	}
+
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "%s Owner Resolution\n", heading)
+	fmt.Fprintln(writer)
+	for _, status := range sortedKeys(byStatus) {
+		fmt.Fprintf(writer, "- **%s**: %d\n", status, byStatus[status])
+	}
+	fmt.Fprintln(writer)
+
+	if len(stale) == 0 {
+		fmt.Fprintln(writer, "All CODEOWNERS entries resolved to current org members or populated teams.")
+		fmt.Fprintln(writer)
+		return
+	}
+
+	fmt.Fprintln(writer, "| Repository | Owner | Kind | Status | Details |")
+	fmt.Fprintln(writer, "|------------|-------|------|--------|---------|")
+	for _, s := range stale {
+		fmt.Fprintf(writer, "| %s | %s | %s | %s | %s |\n",
+			escapeMarkdown(s.repo),
+			escapeMarkdown(s.detail.Handle),
+			s.detail.Kind,
+			s.detail.Status,
+			escapeMarkdown(formatOwnerDetail(s.detail)),
+		)
+	}
+	fmt.Fprintln(writer)
+}
+
+// Describes who an owner entry points at: team members, or a user's name/email
+func formatOwnerDetail(detail inventory.OwnerDetail) string {
+	if len(detail.Members) > 0 {
+		return strings.Join(detail.Members, ", ")
+	}
+	var parts []string
+	if detail.DisplayName != "" {
+		parts = append(parts, detail.DisplayName)
+	}
+	if detail.Email != "" && detail.Email != detail.Handle {
+		parts = append(parts, detail.Email)
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Formats an owner list, or flags it as unowned
+func formatOwnerList(owners []string) string {
+	if len(owners) == 0 {
+		return "_no owner_"
+	}
+	return escapeMarkdown(strings.Join(owners, ", "))
+}
+
+// Returns the paths of a path -> owners map in sorted order
+func sortedOwnerPaths(pathOwners map[string][]string) []string {
+	paths := make([]string, 0, len(pathOwners))
+	for p := range pathOwners {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Writes AWS inventory as markdown, with section headings at the given level ("##", "###")
+func writeAWSMarkdown(writer io.Writer, inv *inventory.Inventory, heading string) error {
 	// Summary statistics
 	summary := generateAWSSummary(inv)
-	fmt.Fprintln(writer, "## Summary")
+	fmt.Fprintf(writer, "%s Summary\n", heading)
 	fmt.Fprintln(writer)
 	fmt.Fprintf(writer, "- **Total Resources**: %d\n", summary.TotalResources)
 
@@ -145,19 +392,52 @@ func writeAWSMarkdown(writer io.Writer, inv *inventory.Inventory) error {
 	fmt.Fprintf(writer, "- **Resources without CI/CD**: %d\n", summary.WithoutCICD)
 	fmt.Fprintln(writer)
 
+	regions := sortedKeys(summary.ByRegion)
+	if len(regions) > 1 {
+		fmt.Fprintln(writer, "**By Region**")
+		fmt.Fprintln(writer)
+		for _, region := range regions {
+			fmt.Fprintf(writer, "- **%s**: %d\n", region, summary.ByRegion[region])
+		}
+		fmt.Fprintln(writer)
+	}
+
 	// Resources table
-	fmt.Fprintln(writer, "## Resources")
+	fmt.Fprintf(writer, "%s Resources\n", heading)
 	fmt.Fprintln(writer)
-	fmt.Fprintln(writer, "| App Name | Owner | Team | Platform | Stack Name | CI/CD | Account |")
-	fmt.Fprintln(writer, "|----------|-------|------|----------|------------|-------|---------|")
 
+	// Single region: one table. Several regions: one table per region.
+	if len(regions) <= 1 {
+		writeAWSMarkdownTable(writer, inv.Resources)
+		return nil
+	}
+
+	byRegion := make(map[string][]*inventory.ResourceInfo)
 	for _, res := range inv.Resources {
+		byRegion[res.Region] = append(byRegion[res.Region], res)
+	}
+	for _, region := range regions {
+		fmt.Fprintf(writer, "%s# %s\n", heading, region)
+		fmt.Fprintln(writer)
+		writeAWSMarkdownTable(writer, byRegion[region])
+		fmt.Fprintln(writer)
+	}
+
+	return nil
+}
+
+// Writes a markdown table of AWS resources
+func writeAWSMarkdownTable(writer io.Writer, resources []*inventory.ResourceInfo) {
+	fmt.Fprintln(writer, "| App Name | Owner | Team | Platform | Stack Name | CI/CD | Account | Region |")
+	fmt.Fprintln(writer, "|----------|-------|------|----------|------------|-------|---------|--------|")
+
+	for _, res := range resources {
 		cicd := "No"
 		if res.HasCICD {
 			cicd = "Yes"
 		}
 
-		fmt.Fprintf(writer, "| %s | %s | %s | %s | %s | %s | %s |\n",
+		fmt.Fprintf(writer, "| %s | %s | %s | %s | %s | %s | %s | %s |\n",
 			escapeMarkdown(res.AppName),
 			escapeMarkdown(res.Owner),
 			escapeMarkdown(res.Team),
@@ -165,10 +445,9 @@ func writeAWSMarkdown(writer io.Writer, inv *inventory.Inventory) error {
 			escapeMarkdown(res.StackName),
 			cicd,
 			escapeMarkdown(res.Account),
+			escapeMarkdown(res.Region),
 		)
 	}
-
-	return nil
 }
 
 // Creates summary statistics for GitHub inventory
@@ -180,6 +459,13 @@ func generateGitHubSummary(inv *inventory.Inventory) Summary {
 	}
 
 	for _, res := range inv.Resources {
+		// Units are counted on their own; the per-repo figures stay per repo
+		if res.UnitPath != "" {
+			summary.TotalResources--
+			summary.DeployableUnits++
+			continue
+		}
+
 		summary.ByPlatform[res.Platform]++
 
 		if res.HasCICD {
@@ -193,6 +479,13 @@ func generateGitHubSummary(inv *inventory.Inventory) Summary {
 		if res.HasCodeOwners {
 			summary.WithCodeOwners++
 		}
+
+		if res.CodeOwnersChecked {
+			summary.CheckedCodeOwners++
+		}
+		if len(res.CodeOwnersErrors) > 0 {
+			summary.InvalidCodeOwners++
+		}
 	}
 
 	return summary
@@ -204,11 +497,13 @@ func generateAWSSummary(inv *inventory.Inventory) Summary {
 		TotalResources: len(inv.Resources),
 		ByPlatform:     make(map[string]int),
 		ByAccount:      make(map[string]int),
+		ByRegion:       make(map[string]int),
 	}
 
 	for _, res := range inv.Resources {
 		summary.ByPlatform[res.Platform]++
 		summary.ByAccount[res.Account]++
+		summary.ByRegion[res.Region]++
 
 		if res.HasCICD {
 			summary.WithCICD++
@@ -222,13 +517,17 @@ func generateAWSSummary(inv *inventory.Inventory) Summary {
 
 // Contains statistics about the inventory
 type Summary struct {
-	TotalResources int
-	ByPlatform     map[string]int
-	ByAccount      map[string]int
-	WithCICD       int
-	WithoutCICD    int
-	WithTests      int
-	WithCodeOwners int
+	TotalResources    int
+	ByPlatform        map[string]int
+	ByAccount         map[string]int
+	ByRegion          map[string]int
+	WithCICD          int
+	WithoutCICD       int
+	WithTests         int
+	WithCodeOwners    int
+	CheckedCodeOwners int // repos whose CODEOWNERS GitHub validated; 0 when --validate-codeowners was off
+	InvalidCodeOwners int // repos whose CODEOWNERS has errors GitHub reported
+	DeployableUnits   int // monorepo units listed besides their repos (--deploy-units)
 }
 
 // Escapes special markdown characters
@@ -239,3 +538,13 @@ func escapeMarkdown(s string) string {
 	)
 	return replacer.Replace(s)
 }
+
+// Returns the keys of a count map in sorted order
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
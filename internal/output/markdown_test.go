@@ -0,0 +1,37 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+func TestGitHubMarkdownCodeOwnersErrorsOnlyWhenValidated(t *testing.T) {
+	tests := []struct {
+		name    string
+		checked bool
+		want    bool
+	}{
+		{"validated", true, true},
+		{"not validated", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv := &inventory.Inventory{Resources: []*inventory.ResourceInfo{
+				{AppName: "api", GitHubRepo: "api", Source: "GitHub", HasCodeOwners: true, CodeOwnersChecked: tt.checked},
+			}}
+
+			var b strings.Builder
+			if err := writeGitHubMarkdown(&b, inv, "##"); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(b.String(), "With CODEOWNERS errors"); got != tt.want {
+				t.Errorf("error count shown = %v, want %v", got, tt.want)
+			}
+			if got := strings.Contains(renderConfluenceStorage(inv), "With CODEOWNERS errors"); got != tt.want {
+				t.Errorf("Confluence error count shown = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
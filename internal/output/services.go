@@ -0,0 +1,135 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Builds the header and one row per correlated service
+func serviceRows(inv *inventory.Inventory) [][]string {
+	rows := [][]string{{"Service", "Repo", "Owner", "CI/CD", "Tests", "AWS Resources", "Accounts"}}
+
+	for _, svc := range inv.Services {
+		repo := "None"
+		tests := "Unknown" // no repo, nothing to inspect
+		if svc.Repo != nil {
+			repo = svc.Repo.GitHubRepo
+			tests = formatBool(svc.HasTests)
+			if svc.HasTests && svc.Repo.TestFramework != "" {
+				tests = fmt.Sprintf("Yes (%s)", svc.Repo.TestFramework)
+			}
+		}
+
+		cicd := svc.CICDPlatform
+		if cicd == "" {
+			cicd = "No"
+		}
+
+		accounts := "None"
+		if len(svc.Accounts) > 0 {
+			accounts = strings.Join(svc.Accounts, ", ")
+		}
+
+		rows = append(rows, []string{
+			svc.Name,
+			repo,
+			svc.Owner,
+			cicd,
+			tests,
+			formatDeployments(svc.Deployments),
+			accounts,
+		})
+	}
+
+	return rows
+}
+
+// Summarizes deployments as "3 (ECS, Lambda)"
+func formatDeployments(deployments []inventory.Deployment) string {
+	if len(deployments) == 0 {
+		return "None"
+	}
+
+	seen := make(map[string]bool)
+	var platforms []string
+	for _, dep := range deployments {
+		if dep.Resource == nil {
+			continue // its row isn't in this inventory
+		}
+		if !seen[dep.Resource.Platform] {
+			seen[dep.Resource.Platform] = true
+			platforms = append(platforms, dep.Resource.Platform)
+		}
+	}
+	sort.Strings(platforms)
+
+	return fmt.Sprintf("%d (%s)", len(deployments), strings.Join(platforms, ", "))
+}
+
+// Writes the service view as a formatted table
+func writeServicesTable(writer io.Writer, inv *inventory.Inventory) error {
+	rows := serviceRows(inv)
+	widths := calculateColumnWidths(rows)
+
+	printTableRow(writer, widths, rows[0]...)
+	printTableSeparator(writer, widths)
+	for _, row := range rows[1:] {
+		printTableRow(writer, widths, row...)
+	}
+
+	return nil
+}
+
+// Writes the service view as markdown
+func writeServicesMarkdown(writer io.Writer, inv *inventory.Inventory) error {
+	var linked, deployed int
+	for _, svc := range inv.Services {
+		if svc.Repo != nil {
+			linked++
+		}
+		if svc.Repo != nil && len(svc.Deployments) > 0 {
+			deployed++
+		}
+	}
+
+	fmt.Fprintln(writer, "## Summary")
+	fmt.Fprintln(writer)
+	fmt.Fprintf(writer, "- **Total Services**: %d\n", len(inv.Services))
+	fmt.Fprintf(writer, "- **Repositories with AWS resources**: %d\n", deployed)
+	fmt.Fprintf(writer, "- **Repositories without AWS resources**: %d\n", linked-deployed)
+	fmt.Fprintf(writer, "- **AWS resources not linked to a repository**: %d services\n", len(inv.Services)-linked)
+	fmt.Fprintln(writer)
+
+	fmt.Fprintln(writer, "## Services")
+	fmt.Fprintln(writer)
+
+	rows := serviceRows(inv)
+	fmt.Fprintf(writer, "| %s |\n", strings.Join(rows[0], " | "))
+	fmt.Fprintf(writer, "|%s\n", strings.Repeat("---|", len(rows[0])))
+	for _, row := range rows[1:] {
+		escaped := make([]string, len(row))
+		for i, cell := range row {
+			escaped[i] = escapeMarkdown(cell)
+		}
+		fmt.Fprintf(writer, "| %s |\n", strings.Join(escaped, " | "))
+	}
+
+	return nil
+}
+
+// Determines the width needed for each column of pre-rendered rows
+func calculateColumnWidths(rows [][]string) []int {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, val := range row {
+			if len(val) > widths[i] {
+				widths[i] = len(val)
+			}
+		}
+	}
+	return widths
+}
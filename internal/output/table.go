@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/ervinmplayon/tractatus/internal/inventory"
@@ -47,20 +48,58 @@ func (w *FiletTableWriter) Write(inv *inventory.Inventory) error {
 
 // File ------------------------------------------------------------------------------------
 
-// Writes the inventory as a formatted table
+// Writes the inventory as a formatted table, followed by a count of what was skipped
 func writeTable(writer io.Writer, inv *inventory.Inventory) error {
+	if err := writeTables(writer, inv); err != nil {
+		return err
+	}
+
+	if len(inv.Skipped) > 0 {
+		groups := groupSkipped(inv.Skipped)
+		var counts []string
+		for _, group := range groups {
+			counts = append(counts, fmt.Sprintf("%d %s %s", len(group.Names), group.Source, group.Reason))
+		}
+		hint := ""
+		if hasEKSSkips(groups) {
+			hint = " (use --include-eks to list EKS workloads)"
+		}
+		fmt.Fprintf(writer, "\nSkipped: %s%s\n", strings.Join(counts, ", "), hint)
+	}
+	return nil
+}
+
+// Writes one table per source present
+func writeTables(writer io.Writer, inv *inventory.Inventory) error {
 	if len(inv.Resources) == 0 {
 		fmt.Fprintln(writer, "No resources found.")
 		return nil
 	}
 
-	// Detect if this is GitHub or AWS data
-	isGitHub := len(inv.Resources) > 0 && inv.Resources[0].GitHubRepo != ""
+	// Correlated run: one row per service
+	if len(inv.Services) > 0 {
+		return writeServicesTable(writer, inv)
+	}
+
+	// Single-source runs get one table; mixed runs get a titled table per source
+	github, aws, other := splitBySource(inv)
+	warnOtherSources(other, "table")
+	switch {
+	case len(github.Resources) == 0 && len(aws.Resources) == 0:
+		fmt.Fprintln(writer, "No GitHub or AWS resources found.")
+		return nil
+	case len(aws.Resources) == 0:
+		return writeGitHubTable(writer, github)
+	case len(github.Resources) == 0:
+		return writeAWSTable(writer, aws)
+	}
 
-	if isGitHub {
-		return writeGitHubTable(writer, inv)
+	fmt.Fprintf(writer, "GitHub Repositories (%d)\n\n", len(github.Resources))
+	if err := writeGitHubTable(writer, github); err != nil {
+		return err
 	}
-	return writeAWSTable(writer, inv)
+	fmt.Fprintf(writer, "\nAWS Resources (%d)\n\n", len(aws.Resources))
+	return writeAWSTable(writer, aws)
 }
 
 // Writes GitHub inventory as a table
@@ -73,6 +112,7 @@ func writeGitHubTable(writer io.Writer, inv *inventory.Inventory) error {
 		"Repo Name",
 		"Owner",
 		"Last Committer",
+		"CODEOWNERS",
 		"Platform",
 		"CI/CD",
 		"Tests",
@@ -115,7 +155,8 @@ func writeGitHubTable(writer io.Writer, inv *inventory.Inventory) error {
 			res.AppName,
 			owners,
 			res.LastCommitter,
-			res.Platform,
+			formatCodeOwnersStatus(res),
+			formatPlatform(res),
 			cicd,
 			tests,
 		)
@@ -138,13 +179,14 @@ func writeAWSTable(writer io.Writer, inv *inventory.Inventory) error {
 		"Stack Name",
 		"CI/CD",
 		"Account",
+		"Region",
 	)
 
 	// Print separator
 	printTableSeparator(writer, widths)
 
-	// Print rows
-	for _, res := range inv.Resources {
+	// Print rows, grouped by region
+	for _, res := range sortByRegion(inv.Resources) {
 		cicd := "No"
 		if res.HasCICD {
 			cicd = "Yes"
@@ -158,6 +200,7 @@ func writeAWSTable(writer io.Writer, inv *inventory.Inventory) error {
 			res.StackName,
 			cicd,
 			res.Account,
+			res.Region,
 		)
 	}
 
@@ -166,7 +209,7 @@ func writeAWSTable(writer io.Writer, inv *inventory.Inventory) error {
 
 // Determines the width needed for each GitHub column
 func calculateGitHubColumnWidths(inv *inventory.Inventory) []int {
-	headers := []string{"Repo Name", "Owner(s)", "Last Committer", "Platform", "CI/CD", "Tests"}
+	headers := []string{"Repo Name", "Owner(s)", "Last Committer", "CODEOWNERS", "Platform", "CI/CD", "Tests"}
 	widths := make([]int, len(headers))
 
 	// Start with header widths
@@ -206,7 +249,8 @@ func calculateGitHubColumnWidths(inv *inventory.Inventory) []int {
 			res.AppName,
 			owners,
 			res.LastCommitter,
-			res.Platform,
+			formatCodeOwnersStatus(res),
+			formatPlatform(res),
 			cicd,
 			tests,
 		}
@@ -223,7 +267,7 @@ func calculateGitHubColumnWidths(inv *inventory.Inventory) []int {
 
 // Determines the width needed for each AWS column
 func calculateAWSColumnWidths(inv *inventory.Inventory) []int {
-	headers := []string{"App Name", "Owner", "Team", "Platform", "Stack Name", "CI/CD", "Account"}
+	headers := []string{"App Name", "Owner", "Team", "Platform", "Stack Name", "CI/CD", "Account", "Region"}
 	widths := make([]int, len(headers))
 
 	// Start with header widths
@@ -241,6 +285,7 @@ func calculateAWSColumnWidths(inv *inventory.Inventory) []int {
 			res.StackName,
 			formatBool(res.HasCICD),
 			res.Account,
+			res.Region,
 		}
 
 		for i, val := range values {
@@ -278,3 +323,38 @@ func formatBool(b bool) string {
 	}
 	return "No"
 }
+
+// Formats a repo's platform with how sure the detection is, and the Kubernetes apps it deploys:
+// "ECS (high)", "EKS/Kubernetes (medium): api, worker"
+func formatPlatform(res *inventory.ResourceInfo) string {
+	platform := res.Platform
+	if res.PlatformConfidence != "" {
+		platform = fmt.Sprintf("%s (%s)", platform, res.PlatformConfidence)
+	}
+	if len(res.KubernetesApps) > 0 {
+		platform += ": " + strings.Join(res.KubernetesApps, ", ")
+	}
+	return platform
+}
+
+// Describes a repo's CODEOWNERS: missing, present, or present but with errors GitHub reported
+func formatCodeOwnersStatus(res *inventory.ResourceInfo) string {
+	switch {
+	case len(res.CodeOwnersErrors) > 0:
+		return fmt.Sprintf("Invalid (%d)", len(res.CodeOwnersErrors))
+	case res.HasCodeOwners:
+		return "Yes"
+	default:
+		return "No"
+	}
+}
+
+// Returns a copy of the resources ordered by region, keeping the original order within a region
+func sortByRegion(resources []*inventory.ResourceInfo) []*inventory.ResourceInfo {
+	sorted := make([]*inventory.ResourceInfo, len(resources))
+	copy(sorted, resources)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Region < sorted[j].Region
+	})
+	return sorted
+}
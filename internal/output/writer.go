@@ -1,7 +1,113 @@
 package output
 
-import "github.com/ervinmplayon/tractatus/internal/inventory"
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Most skipped names listed per source and reason before "+N more"
+const maxSkippedNames = 20
 
 type OutputWriter interface {
 	Write(inv *inventory.Inventory) error
 }
+
+// Splits a possibly mixed inventory by source, keeping the original order.
+// Rows from any other source land in other, which only the mixed CSV layout can show.
+func splitBySource(inv *inventory.Inventory) (github, aws, other *inventory.Inventory) {
+	github = &inventory.Inventory{}
+	aws = &inventory.Inventory{}
+	other = &inventory.Inventory{}
+	for _, res := range inv.Resources {
+		source := res.Source
+		if source == "" {
+			// Older saved inventories have no Source; GitHub resources always carry the repo
+			source = "AWS"
+			if res.GitHubRepo != "" {
+				source = "GitHub"
+			}
+		}
+
+		switch source {
+		case "GitHub":
+			github.Resources = append(github.Resources, res)
+		case "AWS":
+			aws.Resources = append(aws.Resources, res)
+		default:
+			other.Resources = append(other.Resources, res)
+		}
+	}
+	return github, aws, other
+}
+
+// Warns that a format left out resources from sources it has no layout for
+func warnOtherSources(other *inventory.Inventory, format string) {
+	if len(other.Resources) == 0 {
+		return
+	}
+	var sources []string
+	for _, res := range other.Resources {
+		if !slices.Contains(sources, res.Source) {
+			sources = append(sources, res.Source)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s output has no layout for %s; left out %d resources (csv lists every source)\n",
+		format, strings.Join(sources, ", "), len(other.Resources))
+}
+
+// Skipped resources of one source for one reason
+type skipGroup struct {
+	Source string
+	Reason string
+	Names  []string
+}
+
+// Groups skipped resources by source and reason, sorted
+func groupSkipped(skipped []inventory.Skipped) []skipGroup {
+	index := make(map[string]int)
+	var groups []skipGroup
+	for _, skip := range skipped {
+		key := skip.Source + "\x00" + skip.Reason
+		i, exists := index[key]
+		if !exists {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, skipGroup{Source: skip.Source, Reason: skip.Reason})
+		}
+		groups[i].Names = append(groups[i].Names, skip.Name)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Source != groups[j].Source {
+			return groups[i].Source < groups[j].Source
+		}
+		return groups[i].Reason < groups[j].Reason
+	})
+	for _, group := range groups {
+		sort.Strings(group.Names)
+	}
+	return groups
+}
+
+// Reports whether any skipped group is EKS workloads, which --include-eks brings back
+func hasEKSSkips(groups []skipGroup) bool {
+	for _, group := range groups {
+		if group.Reason == "EKS" {
+			return true
+		}
+	}
+	return false
+}
+
+// Lists a group's names, capped at maxSkippedNames
+func formatSkippedNames(names []string) string {
+	if len(names) <= maxSkippedNames {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s (+%d more)", strings.Join(names[:maxSkippedNames], ", "), len(names)-maxSkippedNames)
+}
@@ -0,0 +1,54 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Returns the app names in order
+func appNames(inv *inventory.Inventory) string {
+	names := make([]string, 0, len(inv.Resources))
+	for _, res := range inv.Resources {
+		names = append(names, res.AppName)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSplitBySource(t *testing.T) {
+	inv := &inventory.Inventory{Resources: []*inventory.ResourceInfo{
+		{AppName: "api", GitHubRepo: "api", Source: "GitHub"},
+		{AppName: "worker", Source: "AWS"},
+		{AppName: "legacy-repo", GitHubRepo: "legacy-repo"}, // saved before Source was set
+		{AppName: "legacy-lambda"},
+		{AppName: "chart", Source: "Helm"},
+		{AppName: "mirror", GitHubRepo: "mirror", Source: "GitLab"}, // carries a repo but isn't GitHub
+	}}
+
+	github, aws, other := splitBySource(inv)
+	if got := appNames(github); got != "api,legacy-repo" {
+		t.Errorf("github = %s", got)
+	}
+	if got := appNames(aws); got != "worker,legacy-lambda" {
+		t.Errorf("aws = %s", got)
+	}
+	if got := appNames(other); got != "chart,mirror" {
+		t.Errorf("other = %s", got)
+	}
+}
+
+func TestWriteCSVListsOtherSources(t *testing.T) {
+	inv := &inventory.Inventory{Resources: []*inventory.ResourceInfo{
+		{AppName: "api", GitHubRepo: "api", Source: "GitHub"},
+		{AppName: "chart", Source: "Helm"},
+	}}
+
+	var b strings.Builder
+	if err := writeCSV(&b, inv, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "Source,") || !strings.Contains(b.String(), "Helm,chart") {
+		t.Errorf("CSV doesn't list the Helm row with its source:\n%s", b.String())
+	}
+}
@@ -0,0 +1,205 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Kinds of field change
+const (
+	ChangeAdded   = "added"   // the field went from empty/No to a value
+	ChangeRemoved = "removed" // the field went from a value to empty/No
+	ChangeChanged = "changed"
+)
+
+// Identifies one side of a diff
+type Ref struct {
+	ID            string    `json:"id"`
+	TakenAt       time.Time `json:"taken_at"`
+	Sources       []string  `json:"sources"`
+	ResourceCount int       `json:"resource_count"`
+}
+
+// What changed between two snapshots
+type Diff struct {
+	From    Ref                       `json:"from"`
+	To      Ref                       `json:"to"`
+	Added   []*inventory.ResourceInfo `json:"added"`
+	Removed []*inventory.ResourceInfo `json:"removed"`
+	Changed []ResourceChange          `json:"changed"`
+}
+
+// A resource present in both snapshots whose tracked fields differ
+type ResourceChange struct {
+	Key     string        `json:"key"`
+	Name    string        `json:"name"`
+	Source  string        `json:"source"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// One tracked field's before and after
+type FieldChange struct {
+	Field string `json:"field"`
+	Kind  string `json:"kind"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Reports whether the two snapshots hold the same resources with the same tracked fields
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Fields compared between snapshots, in report order. Values are formatted the way the reports show them.
+// Last commit details are left out on purpose: they change on every push and would drown the rest.
+var trackedFields = []struct {
+	name  string
+	value func(res *inventory.ResourceInfo) string
+}{
+	{"Owner", func(res *inventory.ResourceInfo) string { return res.Owner }},
+	{"Team", func(res *inventory.ResourceInfo) string { return res.Team }},
+	{"Code Owners", func(res *inventory.ResourceInfo) string { return strings.Join(res.CodeOwners, ", ") }},
+	{"CODEOWNERS", func(res *inventory.ResourceInfo) string { return yesNo(res.HasCodeOwners) }},
+	{"Platform", func(res *inventory.ResourceInfo) string { return res.Platform }},
+	{"Environments", func(res *inventory.ResourceInfo) string { return strings.Join(res.DeployEnvironments, ", ") }},
+	{"CI/CD", func(res *inventory.ResourceInfo) string {
+		if res.CICDPlatform != "" {
+			return res.CICDPlatform
+		}
+		return yesNo(res.HasCICD)
+	}},
+	{"Tests", func(res *inventory.ResourceInfo) string {
+		if res.HasTests && res.TestFramework != "" {
+			return fmt.Sprintf("Yes (%s)", res.TestFramework)
+		}
+		return yesNo(res.HasTests)
+	}},
+	{"Stack Name", func(res *inventory.ResourceInfo) string { return res.StackName }},
+	{"Archived", func(res *inventory.ResourceInfo) string { return yesNo(res.IsArchived) }},
+}
+
+// Compares two snapshots. Resources are matched by Key; results are sorted by key.
+func Compare(from, to *Snapshot) *Diff {
+	diff := &Diff{
+		From:    refOf(from),
+		To:      refOf(to),
+		Added:   []*inventory.ResourceInfo{},
+		Removed: []*inventory.ResourceInfo{},
+		Changed: []ResourceChange{},
+	}
+
+	before := index(from.Inventory.Resources)
+	after := index(to.Inventory.Resources)
+
+	for _, key := range sortedKeys(after) {
+		old, exists := before[key]
+		if !exists {
+			diff.Added = append(diff.Added, after[key])
+			continue
+		}
+
+		res := after[key]
+		var changes []FieldChange
+		for _, field := range trackedFields {
+			oldValue, newValue := field.value(old), field.value(res)
+			// "" and "Unknown" both mean nothing was found; don't report one turning into the other
+			if oldValue != newValue && !(isUnset(oldValue) && isUnset(newValue)) {
+				changes = append(changes, FieldChange{
+					Field: field.name,
+					Kind:  changeKind(oldValue, newValue),
+					Old:   oldValue,
+					New:   newValue,
+				})
+			}
+		}
+		if len(changes) > 0 {
+			diff.Changed = append(diff.Changed, ResourceChange{
+				Key:     key,
+				Name:    res.AppName,
+				Source:  SourceOf(res),
+				Changes: changes,
+			})
+		}
+	}
+
+	for _, key := range sortedKeys(before) {
+		if _, exists := after[key]; !exists {
+			diff.Removed = append(diff.Removed, before[key])
+		}
+	}
+
+	return diff
+}
+
+// Returns the identity a resource keeps across runs, see inventory.ResourceInfo.Key
+func Key(res *inventory.ResourceInfo) string {
+	return res.Key()
+}
+
+// Returns the resource's source, falling back for inventories saved before Source was set
+func SourceOf(res *inventory.ResourceInfo) string {
+	switch {
+	case res.Source != "":
+		return res.Source
+	case res.GitHubRepo != "":
+		return "github"
+	}
+	return "aws"
+}
+
+// Maps resources by Key. Repeated keys get a #n suffix so no resource is lost.
+func index(resources []*inventory.ResourceInfo) map[string]*inventory.ResourceInfo {
+	byKey := make(map[string]*inventory.ResourceInfo, len(resources))
+	for _, res := range resources {
+		key := Key(res)
+		for n := 2; byKey[key] != nil; n++ {
+			key = fmt.Sprintf("%s#%d", Key(res), n)
+		}
+		byKey[key] = res
+	}
+	return byKey
+}
+
+func sortedKeys(byKey map[string]*inventory.ResourceInfo) []string {
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func refOf(snap *Snapshot) Ref {
+	return Ref{
+		ID:            snap.ID,
+		TakenAt:       snap.TakenAt,
+		Sources:       snap.Sources,
+		ResourceCount: len(snap.Inventory.Resources),
+	}
+}
+
+// Classifies a change: to or from an empty/No/Unknown value is an addition or removal
+func changeKind(oldValue, newValue string) string {
+	switch {
+	case isUnset(oldValue):
+		return ChangeAdded
+	case isUnset(newValue):
+		return ChangeRemoved
+	}
+	return ChangeChanged
+}
+
+func isUnset(value string) bool {
+	return value == "" || value == "No" || value == "Unknown"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
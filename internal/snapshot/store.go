@@ -0,0 +1,244 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Default directory snapshots are saved to and read from
+const DefaultDir = ".tractatus/snapshots"
+
+// Version of the snapshot file layout. Resources use the inventory.ResourceInfo json tags,
+// so adding resource fields keeps the version; changing the envelope bumps it.
+const FormatVersion = 2 // 2 records the collection options
+
+// Snapshot IDs are the UTC collection time, which also makes file names sort by age
+const idLayout = "20060102T150405Z"
+
+// One saved inventory run
+type Snapshot struct {
+	FormatVersion int                  `json:"format_version"`
+	ID            string               `json:"id"`
+	TakenAt       time.Time            `json:"taken_at"`
+	Sources       []string             `json:"sources"`
+	Options       map[string]string    `json:"options,omitempty"` // collection settings that shape the results, see --baseline
+	Inventory     *inventory.Inventory `json:"inventory"`
+}
+
+// Directory of snapshot files, one <id>.json per run
+type Store struct {
+	dir string
+}
+
+// Returns a store over dir. The directory is created on the first Save.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Returns the store's directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Saves the inventory as a new snapshot taken now, recording the options it was collected with
+func (s *Store) Save(inv *inventory.Inventory, sources []string, options map[string]string) (*Snapshot, error) {
+	takenAt := time.Now().UTC().Truncate(time.Second)
+	snap := &Snapshot{
+		FormatVersion: FormatVersion,
+		ID:            takenAt.Format(idLayout),
+		TakenAt:       takenAt,
+		Sources:       sources,
+		Options:       options,
+		Inventory:     inv,
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("[Save] error: %w", err)
+	}
+
+	// Runs finishing in the same second (a GitHub and an AWS job from one cron entry) get -2, -3, ...
+	// Linking the temp file into place fails instead of replacing an existing snapshot.
+	base := snap.ID
+	for seq := 1; ; seq++ {
+		if seq > 1 {
+			snap.ID = fmt.Sprintf("%s-%d", base, seq)
+		}
+		saved, err := s.write(snap)
+		if err != nil {
+			return nil, err
+		}
+		if saved {
+			return snap, nil
+		}
+	}
+}
+
+// Writes the snapshot to <id>.json unless that file exists; saved is false when it does.
+// The temp file keeps an interrupted run from leaving half a snapshot.
+func (s *Store) write(snap *Snapshot) (saved bool, err error) {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return false, fmt.Errorf("[Save] error: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, snap.ID+".*.tmp")
+	if err != nil {
+		return false, fmt.Errorf("[Save] error: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // the snapshot keeps its own link
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return false, fmt.Errorf("[Save] error: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return false, fmt.Errorf("[Save] error: %w", err)
+	}
+
+	if err := os.Link(tmp.Name(), filepath.Join(s.dir, snap.ID+".json")); err != nil {
+		if os.IsExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("[Save] error: %w", err)
+	}
+	return true, nil
+}
+
+// Returns the IDs of all saved snapshots, oldest first
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("[List] error: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		id := strings.TrimSuffix(name, ".json")
+		if _, _, ok := parseID(id); !ok {
+			continue // not one of ours
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ti, seqI, _ := parseID(ids[i])
+		tj, seqJ, _ := parseID(ids[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return seqI < seqJ
+	})
+	return ids, nil
+}
+
+// Splits a snapshot ID into its time and its sequence within that second:
+// "20260101T090000Z" is the first of the second, "20260101T090000Z-2" the second
+func parseID(id string) (takenAt time.Time, seq int, ok bool) {
+	stamp, seq := id, 1
+	if base, suffix, found := strings.Cut(id, "-"); found {
+		n, err := strconv.Atoi(suffix)
+		if err != nil || n < 2 {
+			return time.Time{}, 0, false
+		}
+		stamp, seq = base, n
+	}
+	takenAt, err := time.Parse(idLayout, stamp)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return takenAt, seq, true
+}
+
+// Loads every snapshot taken at or after since (zero time for all), oldest first
+func (s *Store) LoadSince(since time.Time) ([]*Snapshot, error) {
+	ids, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var snaps []*Snapshot
+	for _, id := range ids {
+		takenAt, _, _ := parseID(id) // List only returns parseable IDs
+		if takenAt.Before(since) {
+			continue
+		}
+		snap, err := s.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+// Loads a snapshot by ID
+func (s *Store) Load(id string) (*Snapshot, error) {
+	return loadFile(filepath.Join(s.dir, id+".json"))
+}
+
+// Loads the snapshot a reference points at:
+// "latest", "previous" (the one before latest), a snapshot ID, or a path to a snapshot or --format json file
+func (s *Store) Resolve(ref string) (*Snapshot, error) {
+	switch ref {
+	case "latest", "previous":
+		ids, err := s.List()
+		if err != nil {
+			return nil, err
+		}
+		back := 1
+		if ref == "previous" {
+			back = 2
+		}
+		if len(ids) < back {
+			return nil, fmt.Errorf("[Resolve] error: no %s snapshot in %s (%d saved)", ref, s.dir, len(ids))
+		}
+		return s.Load(ids[len(ids)-back])
+	}
+
+	if _, _, ok := parseID(ref); ok {
+		return s.Load(ref)
+	}
+	return loadFile(ref)
+}
+
+// Reads and decodes one snapshot file
+func loadFile(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("[loadFile] error: %w", err)
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("[loadFile] error: %s: %w", path, err)
+	}
+	if snap.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("[loadFile] error: %s: format version %d is newer than supported (%d)", path, snap.FormatVersion, FormatVersion)
+	}
+	if snap.Inventory == nil {
+		// Also accept a --format json output file, whose inventory fields sit at the top level
+		var inv inventory.Inventory
+		if err := json.Unmarshal(data, &inv); err != nil {
+			return nil, fmt.Errorf("[loadFile] error: %s: %w", path, err)
+		}
+		snap.Inventory = &inv
+		if snap.ID == "" {
+			snap.ID = filepath.Base(path)
+		}
+	}
+	snap.Inventory.LinkServices()
+	return &snap, nil
+}
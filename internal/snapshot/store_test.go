@@ -0,0 +1,40 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+func TestSaveLoadRelinksServices(t *testing.T) {
+	repo := &inventory.ResourceInfo{AppName: "billing", GitHubRepo: "billing", Source: "GitHub"}
+	lambda := &inventory.ResourceInfo{AppName: "billing-worker", ARN: "arn:aws:lambda:us-east-1:1:function:billing-worker",
+		StackName: "billing-prod", Platform: "Lambda", Source: "AWS"}
+	inv := &inventory.Inventory{Resources: []*inventory.ResourceInfo{repo, lambda}}
+	inv.Services = inventory.Correlate(inv.Resources)
+
+	store := NewStore(t.TempDir())
+	saved, err := store.Save(inv, []string{"GitHub", "AWS"}, nil)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := store.Load(saved.ID)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	services := loaded.Inventory.Services
+	if len(services) != 1 {
+		t.Fatalf("got %d services, want 1", len(services))
+	}
+	svc := services[0]
+	if svc.Repo != loaded.Inventory.Resources[0] {
+		t.Errorf("service repo = %+v, want the loaded billing row", svc.Repo)
+	}
+	if len(svc.Deployments) != 1 || svc.Deployments[0].Resource != loaded.Inventory.Resources[1] {
+		t.Fatalf("deployments = %+v, want the loaded lambda row", svc.Deployments)
+	}
+	if svc.Deployments[0].Resource.Platform != "Lambda" {
+		t.Errorf("deployment platform = %q, want Lambda", svc.Deployments[0].Resource.Platform)
+	}
+}
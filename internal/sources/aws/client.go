@@ -3,23 +3,32 @@ package aws
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/ervinmplayon/tractatus/internal/cache"
 	"github.com/ervinmplayon/tractatus/internal/config"
 )
 
 // Client wraps AWS SDK clients
 type Client struct {
-	taggingClient *resourcegroupstaggingapi.Client
-	accountName   string
+	cfg         aws.Config
+	accountName string
+	caller      string       // ARN the credentials resolve to; part of every cache key
+	regions     []string     // regions to scan; a fresh tagging client is built per region
+	cache       *cache.Cache // per-region results, nil to always fetch
 }
 
-// Creates a new AWS client for the given account
-func NewClient(ctx context.Context, accountName string, useProfile bool, account *config.Account) (*Client, error) {
+// Creates a new AWS client for the given account.
+// regions lists the regions to scan: empty means the profile/config region only, "all" means every enabled region.
+// responseCache may be nil.
+func NewClient(ctx context.Context, accountName string, useProfile bool, account *config.Account, regions []string, responseCache *cache.Cache) (*Client, error) {
 	var cfg aws.Config
 	var err error
 	if useProfile {
@@ -46,9 +55,46 @@ func NewClient(ctx context.Context, accountName string, useProfile bool, account
 		return nil, fmt.Errorf("newClient: failed to load AWS config: %w", err)
 	}
 
+	// Cached results are scoped to who the credentials really are: a profile or role can be repointed
+	// to another account while the config name stays the same
+	var caller string
+	if responseCache != nil {
+		caller, err = callerIdentity(ctx, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: account %s: not caching AWS responses, failed to identify the caller: %v\n", accountName, err)
+			responseCache = nil
+		}
+	}
+
+	// Fall back to the regions listed for the account in config.json
+	if len(regions) == 0 && account != nil {
+		regions = account.Regions
+	}
+
+	switch {
+	case len(regions) == 1 && regions[0] == "all":
+		key := cacheKey(accountName, caller, "regions")
+		if fresh, _ := responseCache.Get(key, &regions); fresh && len(regions) > 0 {
+			break
+		}
+		regions, err = listEnabledRegions(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("newClient: failed to list enabled regions: %w", err)
+		}
+		responseCache.Put(key, regions)
+	case len(regions) == 0:
+		if cfg.Region == "" {
+			return nil, fmt.Errorf("newClient: no region configured for account %s", accountName)
+		}
+		regions = []string{cfg.Region}
+	}
+
 	return &Client{
-		taggingClient: resourcegroupstaggingapi.NewFromConfig(cfg),
-		accountName:   accountName,
+		cfg:         cfg,
+		accountName: accountName,
+		caller:      caller,
+		regions:     regions,
+		cache:       responseCache,
 	}, nil
 }
 
@@ -70,10 +116,44 @@ type Resource struct {
 	Tags     map[string]string
 	Platform string
 	Account  string
+	Region   string
 }
 
-// Fetch all non-EKS resources
+// Fetch all resources in every configured region
 func (c *Client) GetResources(ctx context.Context) ([]Resource, error) {
+	var allResources []Resource
+	var failed int
+
+	for _, region := range c.regions {
+		resources, err := c.getRegionResources(ctx, region)
+		if err != nil {
+			// A disabled or unreachable region shouldn't hide the rest of the account
+			fmt.Fprintf(os.Stderr, "Warning: account %s region %s: %v\n", c.accountName, region, err)
+			failed++
+			continue
+		}
+		allResources = append(allResources, resources...)
+	}
+
+	if failed > 0 && failed == len(c.regions) {
+		return nil, fmt.Errorf("getResources: failed in all %d regions", failed)
+	}
+
+	return allResources, nil
+}
+
+// Fetch all resources in a single region; EKS ones are flagged by the data source
+func (c *Client) getRegionResources(ctx context.Context, region string) ([]Resource, error) {
+	key := cacheKey(c.accountName, c.caller, "resources", region, strings.Join(ResourceTypes, ","))
+	var cached []Resource
+	if fresh, _ := c.cache.Get(key, &cached); fresh {
+		return cached, nil
+	}
+
+	taggingClient := resourcegroupstaggingapi.NewFromConfig(c.cfg, func(o *resourcegroupstaggingapi.Options) {
+		o.Region = region
+	})
+
 	var allResources []Resource
 	var paginationToken *string
 
@@ -88,18 +168,13 @@ func (c *Client) GetResources(ctx context.Context) ([]Resource, error) {
 		}
 
 		// GRAB ALL OF THEM!
-		result, err := c.taggingClient.GetResources(ctx, input)
+		result, err := taggingClient.GetResources(ctx, input)
 		if err != nil {
-			return nil, fmt.Errorf("getResources: failed to get resources: %w", err)
+			return nil, fmt.Errorf("getRegionResources: failed to get resources: %w", err)
 		}
 
 		for _, mapping := range result.ResourceTagMappingList {
-			resource := c.processResource(mapping)
-
-			// filter out EKS resources
-			if !isEKSResource(resource.Tags) {
-				allResources = append(allResources, resource)
-			}
+			allResources = append(allResources, c.processResource(mapping, region))
 		}
 
 		// check for more pages
@@ -109,11 +184,26 @@ func (c *Client) GetResources(ctx context.Context) ([]Resource, error) {
 		paginationToken = result.PaginationToken
 	}
 
+	c.cache.Put(key, allResources)
 	return allResources, nil
 }
 
+// Builds a cache key for one account's request, scoped to the caller the credentials resolve to
+func cacheKey(accountName, caller string, parts ...string) string {
+	return strings.Join(append([]string{"aws", accountName, caller}, parts...), "\x00")
+}
+
+// Returns the ARN of the user or role the config's credentials belong to, which includes the account ID
+func callerIdentity(ctx context.Context, cfg aws.Config) (string, error) {
+	identity, err := sts.NewFromConfig(cfg).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(identity.Arn), nil
+}
+
 // Converts AWS resource tag mapping to our Resource struct
-func (c *Client) processResource(mapping types.ResourceTagMapping) Resource {
+func (c *Client) processResource(mapping types.ResourceTagMapping, region string) Resource {
 	// convert the tags to map
 	tags := make(map[string]string)
 	for _, tag := range mapping.Tags {
@@ -131,10 +221,11 @@ func (c *Client) processResource(mapping types.ResourceTagMapping) Resource {
 		Tags:     tags,
 		Platform: platform,
 		Account:  c.accountName,
+		Region:   region,
 	}
 }
 
-// Check if a resource belongs to EKS
+// Check if a resource belongs to EKS (a cluster's nodes, node groups and load balancers)
 func isEKSResource(tags map[string]string) bool {
 	// Check for EKS-specific tags
 	if _, exists := tags["aws:eks:cluster-name"]; exists {
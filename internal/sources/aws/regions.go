@@ -0,0 +1,31 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// Lists the regions enabled for the account behind cfg.
+// DescribeRegions only returns regions that are enabled by default or opted in, which is exactly what we want to scan.
+func listEnabledRegions(ctx context.Context, cfg aws.Config) ([]string, error) {
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("listEnabledRegions: a home region is required to discover regions")
+	}
+
+	output, err := ec2.NewFromConfig(cfg).DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("listEnabledRegions: %w", err)
+	}
+
+	regions := make([]string, 0, len(output.Regions))
+	for _, r := range output.Regions {
+		regions = append(regions, aws.ToString(r.RegionName))
+	}
+	sort.Strings(regions)
+
+	return regions, nil
+}
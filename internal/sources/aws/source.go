@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ervinmplayon/tractatus/internal/cache"
 	"github.com/ervinmplayon/tractatus/internal/config"
 	"github.com/ervinmplayon/tractatus/internal/inventory"
 )
@@ -12,13 +13,20 @@ type DataSource struct {
 	accountName string
 	account     *config.Account
 	useProfile  bool
+	regions     []string
+	includeEKS  bool                // keep EKS resources, labelled EKS/Kubernetes, instead of skipping them
+	cache       *cache.Cache        // on-disk response cache, nil to always fetch
+	skipped     []inventory.Skipped // EKS resources left out by the last Collect
 }
 
-func NewDataSource(accountName string, account *config.Account, useProfile bool) *DataSource {
+func NewDataSource(accountName string, account *config.Account, useProfile bool, regions []string, includeEKS bool, responseCache *cache.Cache) *DataSource {
 	return &DataSource{
 		accountName: accountName,
 		account:     account,
 		useProfile:  useProfile,
+		regions:     regions,
+		includeEKS:  includeEKS,
+		cache:       responseCache,
 	}
 }
 
@@ -30,32 +38,60 @@ func (ds *DataSource) Name() string {
 // Fetches resources from AWS
 func (ds *DataSource) Collect(ctx context.Context) ([]*inventory.ResourceInfo, error) {
 	// Create AWS client
-	client, err := NewClient(ctx, ds.accountName, ds.useProfile, ds.account)
+	client, err := NewClient(ctx, ds.accountName, ds.useProfile, ds.account, ds.regions, ds.cache)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create AWS client: %w", err)
+		return nil, fmt.Errorf("account %s: failed to create AWS client: %w", ds.accountName, err)
 	}
 
 	// Get resources
 	resources, err := client.GetResources(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get resources: %w", err)
+		return nil, fmt.Errorf("account %s: failed to get resources: %w", ds.accountName, err)
 	}
 
-	// Transform to ResourceInfo
+	// Transform to ResourceInfo; EKS workloads are inventoried elsewhere unless asked for
+	ds.skipped = nil
 	var resourceInfos []*inventory.ResourceInfo
 	for _, res := range resources {
 		info := enrichResource(res)
+		if isEKSResource(res.Tags) {
+			if !ds.includeEKS {
+				ds.skipped = append(ds.skipped, inventory.Skipped{Name: eksName(info), Reason: "EKS"})
+				continue
+			}
+			info.Platform = inventory.PlatformEKS
+		}
 		resourceInfos = append(resourceInfos, &info)
 	}
 
 	return resourceInfos, nil
 }
 
+// Returns the EKS resources the last Collect left out
+func (ds *DataSource) Skipped() []inventory.Skipped {
+	return ds.skipped
+}
+
+// Names a skipped EKS resource: its cluster and app name, or ARN when it has no name
+func eksName(info inventory.ResourceInfo) string {
+	name := info.AppName
+	if name == "Unknown" {
+		name = info.ARN
+	}
+	for _, key := range []string{"aws:eks:cluster-name", "eks:cluster-name"} {
+		if cluster := info.ResourceTags[key]; cluster != "" {
+			return cluster + "/" + name
+		}
+	}
+	return name
+}
+
 // Extracts and enriches resource information from tags
 func enrichResource(res Resource) inventory.ResourceInfo {
 	info := inventory.ResourceInfo{
 		Platform:     res.Platform,
 		Account:      res.Account,
+		Region:       res.Region,
 		ARN:          res.ARN,
 		ResourceTags: res.Tags,
 	}
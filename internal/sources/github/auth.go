@@ -0,0 +1,148 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// GitHub App credentials: the app authenticates with a short-lived JWT and trades it for an installation token
+type AppAuth struct {
+	AppID          int64
+	InstallationID int64 // 0 looks up the app's installation on the org
+	PrivateKey     *rsa.PrivateKey
+}
+
+// Reads a GitHub App private key (the .pem GitHub generates, PKCS#1 or PKCS#8)
+func LoadPrivateKey(filename string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("loadPrivateKey: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("loadPrivateKey: %s: no PEM block found", filename)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("loadPrivateKey: %s: %w", filename, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("loadPrivateKey: %s: not an RSA key", filename)
+	}
+	return key, nil
+}
+
+// Hands out installation tokens; wrapped in oauth2.ReuseTokenSource so a new one is minted shortly before
+// the current one expires (they last an hour)
+type appTokenSource struct {
+	ctx     context.Context
+	app     *AppAuth
+	baseURL *url.URL // REST API root, api.github.com or <host>/api/v3/
+	org     string
+	http    *http.Client
+}
+
+func newAppTokenSource(ctx context.Context, app *AppAuth, baseURL *url.URL, org string) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &appTokenSource{
+		ctx:     ctx,
+		app:     app,
+		baseURL: baseURL,
+		org:     org,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	})
+}
+
+// Mints a new installation access token
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.appJWT()
+	if err != nil {
+		return nil, err
+	}
+
+	installationID := s.app.InstallationID
+	if installationID == 0 {
+		var installation struct {
+			ID int64 `json:"id"`
+		}
+		if err := s.call(http.MethodGet, "orgs/"+url.PathEscape(s.org)+"/installation", jwt, &installation); err != nil {
+			return nil, fmt.Errorf("[appToken] error: app %d is not installed on %s: %w", s.app.AppID, s.org, err)
+		}
+		installationID = installation.ID
+		s.app.InstallationID = installationID // looked up once
+	}
+
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := s.call(http.MethodPost, fmt.Sprintf("app/installations/%d/access_tokens", installationID), jwt, &token); err != nil {
+		return nil, fmt.Errorf("[appToken] error: %w", err)
+	}
+
+	return &oauth2.Token{AccessToken: token.Token, TokenType: "Bearer", Expiry: token.ExpiresAt}, nil
+}
+
+// Makes an app-authenticated API call and decodes the JSON answer
+func (s *appTokenSource) call(method, path, jwt string, out any) error {
+	endpoint := s.baseURL.ResolveReference(&url.URL{Path: path})
+	req, err := http.NewRequestWithContext(s.ctx, method, endpoint.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("%s %s: %s %s", method, endpoint.Path, resp.Status, body.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Signs the RS256 JWT GitHub expects from an app: issued a minute ago for clock drift, valid for nine minutes
+func (s *appTokenSource) appJWT() (string, error) {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": fmt.Sprint(s.app.AppID),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.app.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("[appJWT] error: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
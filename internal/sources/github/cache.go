@@ -0,0 +1,134 @@
+package github
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ervinmplayon/tractatus/internal/cache"
+)
+
+// Wraps an http.RoundTripper with the on-disk response cache.
+//
+// Fresh entries (younger than the TTL) are served without a request. Stale ones are revalidated with
+// If-None-Match / If-Modified-Since: a 304 costs no rate limit and the cached body is reused.
+// Only GETs are cached, and only 200s and 404s (a missing CODEOWNERS location is as stable as a file).
+// Paginated lists (the org's repo listing, last commit, team members) are revalidated on every run regardless
+// of age: pages cached at different times would skip or repeat repos, and a stale pushed_at hides pushes.
+type cacheTransport struct {
+	base      http.RoundTripper
+	cache     *cache.Cache
+	namespace string // per token or app installation, so one identity never sees what another fetched
+}
+
+// Cached form of a response
+type cachedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+func newCacheTransport(base http.RoundTripper, responseCache *cache.Cache, identity string) *cacheTransport {
+	sum := sha256.Sum256([]byte(identity))
+	return &cacheTransport{
+		base:      base,
+		cache:     responseCache,
+		namespace: hex.EncodeToString(sum[:8]),
+	}
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	key := strings.Join([]string{"github", t.namespace, req.Header.Get("Accept"), req.URL.String()}, "\x00")
+	var cached cachedResponse
+	fresh, found := t.cache.Get(key, &cached)
+	if fresh && !isListRequest(req) {
+		return cached.response(req, nil), nil
+	}
+
+	// Ask GitHub whether the stale copy still holds
+	sent := req
+	if found {
+		etag, modified := cached.Header.Get("ETag"), cached.Header.Get("Last-Modified")
+		if etag != "" || modified != "" {
+			sent = req.Clone(req.Context())
+			if etag != "" {
+				sent.Header.Set("If-None-Match", etag)
+			}
+			if modified != "" {
+				sent.Header.Set("If-Modified-Since", modified)
+			}
+		}
+	}
+
+	resp, err := t.base.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && found {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		t.cache.Put(key, cached) // restamp: good for another TTL
+		return cached.response(req, resp.Header), nil
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return resp, nil // errors and rate limits are never cached
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.cache.Put(key, cachedResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: body})
+	return resp, nil
+}
+
+// Reports whether the request fetches a page of a list endpoint
+func isListRequest(req *http.Request) bool {
+	query := req.URL.Query()
+	return query.Has("per_page") || query.Has("page")
+}
+
+// Rebuilds an http.Response from the cache. Rate-limit headers come from live (the 304) when there is one
+// and are dropped otherwise, so go-github never acts on an old quota.
+func (c cachedResponse) response(req *http.Request, live http.Header) *http.Response {
+	header := c.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for name := range header {
+		if strings.HasPrefix(strings.ToLower(name), "x-ratelimit-") {
+			header.Del(name)
+		}
+	}
+	for name, values := range live {
+		if strings.HasPrefix(strings.ToLower(name), "x-ratelimit-") {
+			header[name] = values
+		}
+	}
+	header.Set("X-From-Cache", "1")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
@@ -2,7 +2,11 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"time"
 
 	"github.com/google/go-github/v57/github"
 	"golang.org/x/oauth2"
@@ -10,46 +14,109 @@ import (
 
 // Wrap the Github API client
 type Client struct {
-	client *github.Client
-	org    string // because reusability
+	client    *github.Client
+	org       string // because reusability
+	workers   int    // repositories fetched concurrently
+	recursive bool   // scan the whole tree instead of root entries
+	maxDepth  int    // deepest path level kept when recursive (1 = root)
+	backend   string // BackendREST or BackendGraphQL
+
+	prefetched prefetchedFiles // CODEOWNERS files the GraphQL backend fetched with the repo
 }
 
-// Create a new GHub API client
-func NewClient(ctx context.Context, token, org string) (*Client, error) {
-	if token == "" {
-		return nil, fmt.Errorf("newClient: github token is required")
+// Create a new GHub API client. Authenticates with the token, or as a GitHub App when opts.App is set.
+func NewClient(ctx context.Context, token, org string, opts Options) (*Client, error) {
+	if token == "" && opts.App == nil {
+		return nil, fmt.Errorf("newClient: github token or app credentials are required")
 	}
 	if org == "" {
 		return nil, fmt.Errorf("newClient: github organization is required")
 	}
 
+	// Resolve the API root first: the app token exchange needs it before the real client exists
+	endpoints, err := newAPIClient(nil, opts)
+	if err != nil {
+		return nil, fmt.Errorf("newClient: invalid GitHub URL: %w", err)
+	}
+
 	// Create OAuth2 token source and create Github client
 	toke_src := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
+	identity := token // what cached responses are scoped to
+	if opts.App != nil {
+		toke_src = newAppTokenSource(ctx, opts.App, endpoints.BaseURL, org)
+		identity = fmt.Sprintf("app:%d:%d:%s", opts.App.AppID, opts.App.InstallationID, org)
+	}
 	toke_client := oauth2.NewClient(ctx, toke_src)
-	client := github.NewClient(toke_client)
+
+	// Pause and retry on rate limits instead of failing the call
+	toke_client.Transport = newRateLimitTransport(toke_client.Transport)
+	// Cache on top, so fresh hits skip the rate limiter and revalidations go through it
+	if opts.Cache != nil {
+		toke_client.Transport = newCacheTransport(toke_client.Transport, opts.Cache, identity)
+	}
+	client, err := newAPIClient(toke_client, opts)
+	if err != nil {
+		return nil, fmt.Errorf("newClient: invalid GitHub URL: %w", err)
+	}
+
+	if opts.Workers < 1 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.MaxDepth < 1 {
+		opts.MaxDepth = DefaultMaxDepth
+	}
 
 	return &Client{
-		client: client,
-		org:    org,
+		client:    client,
+		org:       org,
+		workers:   opts.Workers,
+		recursive: opts.Recursive,
+		maxDepth:  opts.MaxDepth,
+		backend:   opts.Backend,
 	}, nil
 }
 
+// Returns a go-github client on httpClient, pointed at GitHub Enterprise Server when opts.BaseURL is set
+func newAPIClient(httpClient *http.Client, opts Options) (*github.Client, error) {
+	client := github.NewClient(httpClient)
+	if opts.BaseURL == "" {
+		return client, nil
+	}
+
+	// Both default to the server root; go-github appends api/v3/ and api/uploads/
+	uploadURL := opts.UploadURL
+	if uploadURL == "" {
+		uploadURL = opts.BaseURL
+	}
+	return client.WithEnterpriseURLs(opts.BaseURL, uploadURL)
+}
+
 // Represents a Github repo with its file tree
 type Repository struct {
 	Name           string
 	IsArchived     bool
 	DefaultBranch  string
 	HTMLURL        string
-	Files          []string // List of file/directory paths at root
+	Files          []string // List of file/directory paths (root and CI config dirs only unless scanning recursively)
+	Dirs           []string // The subset of Files that are directories
 	LastCommitter  string
 	LastCommitDate string
+	PushedAt       string // RFC 3339
+	Unchanged      bool   // listed only: the tree and last commit weren't fetched (see ListRepositories)
+	PartialTree    bool   // the tree walk stopped at maxTreeWalkCalls, so Files misses part of the repo
 }
 
-// Fetch all the repon in an org
-func (c *Client) ListRepositories(ctx context.Context, excludeArchived bool) ([]*Repository, error) {
-	var allRepos []*Repository
+// Fetch all the repon in an org.
+// Repos unchanged reports true for keep only their listing metadata and are marked Unchanged;
+// a nil unchanged fetches every repo.
+func (c *Client) ListRepositories(ctx context.Context, excludeArchived bool, unchanged func(*Repository) bool) ([]*Repository, error) {
+	if c.backend == BackendGraphQL {
+		return c.listRepositoriesGraphQL(ctx, excludeArchived, unchanged)
+	}
+
+	var listed []*github.Repository
 
 	options := &github.RepositoryListByOrgOptions{
 		ListOptions: github.ListOptions{
@@ -57,6 +124,7 @@ func (c *Client) ListRepositories(ctx context.Context, excludeArchived bool) ([]
 		},
 	}
 
+	// Page through the org first; the per-repo calls below are the expensive part
 	for {
 		repos, resp, err := c.client.Repositories.ListByOrg(ctx, c.org, options)
 		if err != nil {
@@ -68,31 +136,7 @@ func (c *Client) ListRepositories(ctx context.Context, excludeArchived bool) ([]
 			if excludeArchived && repo.GetArchived() {
 				continue
 			}
-
-			// Get file tree for the repository
-			files, err := c.getFileTree(ctx, repo.GetName(), repo.GetDefaultBranch())
-			if err != nil {
-				// Log warning but continue
-				fmt.Printf("Warning: failed to get file tree for %s: %v\n", repo.GetName(), err)
-				files = []string{}
-			}
-
-			// Get last commit info
-			lastCommitter, lastCommitDate, err := c.getLastCommit(ctx, repo.GetName(), repo.GetDefaultBranch())
-			if err != nil {
-				// Log warning but continue
-				fmt.Printf("Warning: failed to get last commit for %s: %v\n", repo.GetName(), err)
-			}
-
-			allRepos = append(allRepos, &Repository{
-				Name:           repo.GetName(),
-				IsArchived:     repo.GetArchived(),
-				DefaultBranch:  repo.GetDefaultBranch(),
-				HTMLURL:        repo.GetHTMLURL(),
-				Files:          files,
-				LastCommitter:  lastCommitter,
-				LastCommitDate: lastCommitDate,
-			})
+			listed = append(listed, repo)
 		}
 
 		if resp.NextPage == 0 {
@@ -101,31 +145,95 @@ func (c *Client) ListRepositories(ctx context.Context, excludeArchived bool) ([]
 		options.Page = resp.NextPage
 	}
 
+	allRepos := make([]*Repository, len(listed))
+	err := runPool(ctx, len(listed), c.workers, func(ctx context.Context, i int) {
+		repo := listedRepository(listed[i])
+		if unchanged != nil && unchanged(repo) {
+			repo.Unchanged = true
+			allRepos[i] = repo
+			return
+		}
+		allRepos[i] = c.fetchRepository(ctx, repo)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listRepositories: %w", err)
+	}
+
 	return allRepos, nil
 }
 
-// Gets the list of the files and directories at the root of a repository
-func (c *Client) getFileTree(ctx context.Context, repoName, branch string) ([]string, error) {
+// Returns the metadata the org listing already has
+func listedRepository(repo *github.Repository) *Repository {
+	pushedAt := ""
+	if repo.PushedAt != nil {
+		pushedAt = repo.GetPushedAt().UTC().Format(time.RFC3339)
+	}
+
+	return &Repository{
+		Name:          repo.GetName(),
+		IsArchived:    repo.GetArchived(),
+		DefaultBranch: repo.GetDefaultBranch(),
+		HTMLURL:       repo.GetHTMLURL(),
+		PushedAt:      pushedAt,
+	}
+}
+
+// Fetches the file tree and last commit for a single repository
+func (c *Client) fetchRepository(ctx context.Context, repo *Repository) *Repository {
+	// Get file tree for the repository
+	files, dirs, partial, err := c.getFileTree(ctx, repo.Name, repo.DefaultBranch)
+	if err != nil {
+		// Log warning but continue
+		fmt.Fprintf(os.Stderr, "Warning: failed to get file tree for %s: %v\n", repo.Name, err)
+		files = []string{}
+	}
+	repo.Files = files
+	repo.Dirs = dirs
+	repo.PartialTree = partial
+
+	// Get last commit info
+	repo.LastCommitter, repo.LastCommitDate, err = c.getLastCommit(ctx, repo.Name, repo.DefaultBranch)
+	if err != nil {
+		// Log warning but continue
+		fmt.Fprintf(os.Stderr, "Warning: failed to get last commit for %s: %v\n", repo.Name, err)
+	}
+
+	return repo
+}
+
+// Gets the list of the files and directories in a repository: root entries only,
+// or the whole tree down to maxDepth when the client scans recursively.
+// partial reports a truncated tree the walk couldn't finish within maxTreeWalkCalls.
+func (c *Client) getFileTree(ctx context.Context, repoName, branch string) (files, dirs []string, partial bool, err error) {
 	if branch == "" {
 		branch = "main" // some repos have a non-main default branch but this is a good fallback for now
 	}
 
-	tree, _, err := c.client.Git.GetTree(ctx, c.org, repoName, branch, false)
+	tree, _, err := c.client.Git.GetTree(ctx, c.org, repoName, branch, c.recursive)
 	if err != nil {
 		// Try master as fallback
-		tree, _, err = c.client.Git.GetTree(ctx, c.org, repoName, "master", false)
+		branch = "master"
+		tree, _, err = c.client.Git.GetTree(ctx, c.org, repoName, branch, c.recursive)
 		if err != nil {
-			return nil, fmt.Errorf("getFileTree error: %w", err)
+			return nil, nil, false, fmt.Errorf("getFileTree error: %w", err)
 		}
 	}
 
 	// This part of the code does not run IF the "main", "master" branches above return due to errs.
-	var files []string
-	for _, entry := range tree.Entries {
-		files = append(files, entry.GetPath())
+	// Root entries, plus the CI config directories so workflows can be read
+	if !c.recursive {
+		files, dirs = collectTreeEntries(tree.Entries, "", 1)
+		ciFiles, ciDirs := c.expandCIDirs(ctx, repoName, tree.Entries)
+		return append(files, ciFiles...), append(dirs, ciDirs...), false, nil
+	}
+
+	// GitHub caps recursive trees (100k entries / 7 MB); walk the tree level by level instead
+	if tree.GetTruncated() {
+		return c.walkTree(ctx, repoName, branch)
 	}
 
-	return files, nil
+	files, dirs = collectTreeEntries(tree.Entries, "", c.maxDepth)
+	return files, dirs, false, nil
 }
 
 // Returns the last commiter and the commit date
@@ -166,6 +274,13 @@ func (c *Client) getLastCommit(ctx context.Context, repoName, branch string) (st
 
 // Fecth the content of a specific file
 func (c *Client) GetFileContent(ctx context.Context, repoName, filePath string) (string, error) {
+	if content, found, ok := c.prefetchedFile(repoName, filePath); ok {
+		if !found {
+			return "", fmt.Errorf("[getFileContent] error: %w", notFoundError(filePath))
+		}
+		return content, nil
+	}
+
 	fileContent, _, _, err := c.client.Repositories.GetContents(ctx, c.org, repoName, filePath, nil)
 	if err != nil {
 		return "", fmt.Errorf("[getFileContent] error: %w", err)
@@ -183,3 +298,91 @@ func (c *Client) GetFileContent(ctx context.Context, repoName, filePath string)
 
 	return content, nil
 }
+
+// Represents a GitHub user as far as ownership reporting cares
+type User struct {
+	Login string
+	Name  string
+	Email string // only set when the user made it public
+}
+
+// Fetch a user by login; returns nil (no error) if the account doesn't exist
+func (c *Client) GetUser(ctx context.Context, login string) (*User, error) {
+	user, _, err := c.client.Users.Get(ctx, login)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("[getUser] error: %w", err)
+	}
+
+	return &User{
+		Login: user.GetLogin(),
+		Name:  user.GetName(),
+		Email: user.GetEmail(),
+	}, nil
+}
+
+// Checks whether a user belongs to the given org
+func (c *Client) IsOrgMember(ctx context.Context, org, login string) (bool, error) {
+	member, _, err := c.client.Organizations.IsMember(ctx, org, login)
+	if err != nil {
+		return false, fmt.Errorf("[isOrgMember] error: %w", err)
+	}
+	return member, nil
+}
+
+// Lists the member logins of org/slug; found is false if the team doesn't exist (or isn't visible to the token)
+func (c *Client) ListTeamMembers(ctx context.Context, org, slug string) (members []string, found bool, err error) {
+	options := &github.TeamListTeamMembersOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		users, resp, err := c.client.Teams.ListTeamMembersBySlug(ctx, org, slug, options)
+		if err != nil {
+			if isNotFound(err) {
+				return nil, false, nil
+			}
+			return nil, false, fmt.Errorf("[listTeamMembers] error: %w", err)
+		}
+
+		for _, user := range users {
+			members = append(members, user.GetLogin())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return members, true, nil
+}
+
+// Lists the problems GitHub found in a repo's CODEOWNERS file on the given ref.
+// GitHub picks the same file it enforces, so this also covers a CODEOWNERS we didn't fetch.
+func (c *Client) GetCodeOwnersErrors(ctx context.Context, repo, ref string) ([]*github.CodeownersError, error) {
+	options := &github.GetCodeownersErrorsOptions{Ref: ref}
+
+	result, _, err := c.client.Repositories.GetCodeownersErrors(ctx, c.org, repo, options)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil // no CODEOWNERS file
+		}
+		return nil, fmt.Errorf("[getCodeOwnersErrors] error: %w", err)
+	}
+
+	return result.Errors, nil
+}
+
+// Returns the organization the client is scoped to
+func (c *Client) Org() string {
+	return c.org
+}
+
+// Reports whether err is a GitHub 404
+func isNotFound(err error) bool {
+	var gerr *github.ErrorResponse
+	return errors.As(err, &gerr) && gerr.Response != nil && gerr.Response.StatusCode == http.StatusNotFound
+}
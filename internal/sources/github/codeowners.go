@@ -0,0 +1,270 @@
+package github
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Most unowned paths reported per repository; deeper ones under an already-unowned directory are folded into it anyway
+const maxUnownedPaths = 50
+
+// One CODEOWNERS entry: a gitignore-style pattern and the owners it assigns
+type CodeOwnersRule struct {
+	Pattern string
+	Owners  []string // empty means "no owner", which un-assigns paths matched by earlier rules
+	Section string   // GitLab-style [Section] the rule belongs to, "" before any section
+	Line    int
+	regex   *regexp.Regexp
+}
+
+// A parsed CODEOWNERS file
+type CodeOwnersFile struct {
+	Rules []CodeOwnersRule
+}
+
+// Parses CODEOWNERS content.
+// Supports comments, gitignore-style patterns and GitLab-style section headers ("[Docs] @docs-team"),
+// whose default owners apply to rules in the section that list none.
+func ParseCodeOwnersFile(content string) *CodeOwnersFile {
+	file := &CodeOwnersFile{}
+	section := ""
+	var sectionOwners []string
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		// Skip comments and empty lines
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Section header: [Name], ^[Optional Name], [Name][2], optionally followed by default owners
+		if strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			if end := strings.Index(line, "]"); end > 0 {
+				section = strings.TrimPrefix(line[:end], "^")
+				section = strings.TrimPrefix(section, "[")
+				rest := line[end+1:]
+				// Skip an approval count like [2]
+				if strings.HasPrefix(rest, "[") {
+					if countEnd := strings.Index(rest, "]"); countEnd >= 0 {
+						rest = rest[countEnd+1:]
+					}
+				}
+				sectionOwners = parseOwnerTokens(strings.Fields(rest))
+				continue
+			}
+		}
+
+		// CODEOWNERS format: path @owner1 @owner2 email@example.com
+		parts := strings.Fields(line)
+		owners := parseOwnerTokens(parts[1:])
+		if len(owners) == 0 && len(parts) == 1 {
+			owners = sectionOwners
+		}
+
+		rule := CodeOwnersRule{
+			Pattern: parts[0],
+			Owners:  owners,
+			Section: section,
+			Line:    i + 1,
+		}
+		rule.regex = compileCodeOwnersPattern(parts[0])
+		file.Rules = append(file.Rules, rule)
+	}
+
+	return file
+}
+
+// Keeps the owner tokens: @user, @org/team or an email address
+func parseOwnerTokens(parts []string) []string {
+	var owners []string
+	for _, part := range parts {
+		if strings.HasPrefix(part, "@") {
+			// GitHub username: @username or @org/team
+			owners = append(owners, strings.TrimPrefix(part, "@"))
+		} else if strings.Contains(part, "@") {
+			// Email address: user@domain.com
+			owners = append(owners, part)
+		}
+		// Anything else isn't an owner (stray text, inline comments)
+	}
+	return owners
+}
+
+// Returns every unique owner in the file, in order of first appearance
+func (f *CodeOwnersFile) AllOwners() []string {
+	var owners []string
+	seen := make(map[string]bool)
+	for _, rule := range f.Rules {
+		for _, owner := range rule.Owners {
+			if !seen[owner] {
+				seen[owner] = true
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+// Resolves the owners of a path. Within a section the last matching rule wins;
+// owners from different sections are combined, as GitLab does (GitHub files have one implicit section).
+func (f *CodeOwnersFile) Owners(filePath string, isDir bool) []string {
+	filePath = strings.Trim(filePath, "/")
+	target := filePath
+	if isDir {
+		target += "/"
+	}
+
+	var sections []string
+	lastMatch := make(map[string]*CodeOwnersRule)
+	for i := range f.Rules {
+		rule := &f.Rules[i]
+		if rule.regex == nil || !rule.regex.MatchString(target) {
+			continue
+		}
+		if _, seen := lastMatch[rule.Section]; !seen {
+			sections = append(sections, rule.Section)
+		}
+		lastMatch[rule.Section] = rule
+	}
+
+	var owners []string
+	seen := make(map[string]bool)
+	for _, section := range sections {
+		for _, owner := range lastMatch[section].Owners {
+			if !seen[owner] {
+				seen[owner] = true
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+// Returns the owners of the catch-all rule (*, /*, **, /**), the repo's default owners
+func (f *CodeOwnersFile) RootOwners() []string {
+	var owners []string
+	seen := make(map[string]bool)
+	lastMatch := make(map[string][]string)
+	var sections []string
+
+	for _, rule := range f.Rules {
+		switch rule.Pattern {
+		case "*", "/*", "**", "/**", "/":
+			if _, exists := lastMatch[rule.Section]; !exists {
+				sections = append(sections, rule.Section)
+			}
+			lastMatch[rule.Section] = rule.Owners
+		}
+	}
+
+	for _, section := range sections {
+		for _, owner := range lastMatch[section] {
+			if !seen[owner] {
+				seen[owner] = true
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+// Lists paths with no owner. A directory that is unowned stands in for everything below it.
+// dirs marks which of files are directories.
+func (f *CodeOwnersFile) UnownedPaths(files []string, dirs map[string]bool) []string {
+	sorted := make([]string, len(files))
+	copy(sorted, files)
+	sort.Strings(sorted)
+
+	var unowned []string
+	unownedDirs := make(map[string]bool)
+	for _, file := range sorted {
+		if underAny(file, unownedDirs) {
+			continue
+		}
+		if len(f.Owners(file, dirs[file])) > 0 {
+			continue
+		}
+		if dirs[file] {
+			unownedDirs[file] = true
+		}
+		unowned = append(unowned, file)
+		if len(unowned) == maxUnownedPaths {
+			break
+		}
+	}
+	return unowned
+}
+
+// Reports whether any parent directory of file is in dirs
+func underAny(file string, dirs map[string]bool) bool {
+	for dir := path.Dir(file); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if dirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// Translates a gitignore-style CODEOWNERS pattern into a regex over slash-separated paths
+// (directories carry a trailing slash).
+//
+//   - a leading "/" or a "/" in the middle anchors the pattern to the repo root; otherwise it matches at any depth
+//   - a trailing "/" matches directories (and so everything inside them) only
+//   - "*" and "?" don't cross "/"; "**" does
+//   - a pattern matching a directory also matches everything inside it, except a trailing "/*":
+//     GitHub documents "docs/*" as owning docs/getting-started.md but not docs/build-app/troubleshooting.md
+func compileCodeOwnersPattern(pattern string) *regexp.Regexp {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.HasPrefix(trimmed, "/") || strings.Contains(strings.TrimPrefix(trimmed, "/"), "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	if trimmed == "" {
+		// "/" on its own: the whole repository
+		return regexp.MustCompile(`^.*$`)
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(trimmed); i++ {
+		c := trimmed[i]
+		switch {
+		case c == '*' && i+1 < len(trimmed) && trimmed[i+1] == '*':
+			// "**/" matches zero or more directories; a bare "**" matches anything
+			if i+2 < len(trimmed) && trimmed[i+2] == '/' {
+				b.WriteString("(?:.*/)?")
+				i += 2
+			} else {
+				b.WriteString(".*")
+				i++
+			}
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	switch {
+	case dirOnly:
+		b.WriteString("/.*$")
+	case anchored && strings.HasSuffix(trimmed, "/*"):
+		b.WriteString("$")
+	default:
+		b.WriteString("(?:/.*)?$")
+	}
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil // unusable pattern; it simply never matches
+	}
+	return re
+}
@@ -0,0 +1,169 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompileCodeOwnersPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string // directories carry a trailing slash, as Owners passes them
+		want    bool
+	}{
+		{"*", "README.md", true},
+		{"*", "src/app/main.go", true},
+		{"*", "docs/", true},
+
+		{"/docs/", "docs/", true},
+		{"/docs/", "docs/guide.md", true},
+		{"/docs/", "docs/api/v1.md", true},
+		{"/docs/", "src/docs/guide.md", false},
+		{"/docs/", "docs", false}, // a file named docs isn't the directory
+
+		{"docs/*", "docs/guide.md", true},
+		{"docs/*", "docs/build/troubleshooting.md", false},
+		{"docs/*", "docs/build/", false},
+		{"docs/*", "src/docs/guide.md", false},
+
+		{"**/logs", "logs", true},
+		{"**/logs", "logs/today.log", true},
+		{"**/logs", "services/api/logs/today.log", true},
+		{"**/logs", "catalogs/x", false},
+
+		{"*.js", "app.js", true},
+		{"*.js", "src/ui/button.js", true},
+		{"*.js", "app.jsx", false},
+
+		{"apps/", "apps/web/index.ts", true},
+		{"apps/", "services/apps/web/index.ts", true},
+		{"/apps/web", "apps/web/", true},
+		{"/apps/web", "apps/website/", false},
+
+		{"/", "anything/at/all", true},
+		{"?.md", "a.md", true},
+		{"?.md", "ab.md", false},
+	}
+	for _, tt := range tests {
+		re := compileCodeOwnersPattern(tt.pattern)
+		if re == nil {
+			t.Errorf("compileCodeOwnersPattern(%q) = nil", tt.pattern)
+			continue
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("pattern %q on %q = %v, want %v (regex %s)", tt.pattern, tt.path, got, tt.want, re)
+		}
+	}
+}
+
+func TestCodeOwnersOwners(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		isDir   bool
+		want    []string
+	}{
+		{
+			name:    "catch-all",
+			content: "* @org/platform",
+			path:    "src/main.go",
+			want:    []string{"org/platform"},
+		},
+		{
+			name:    "later rule overrides an earlier one",
+			content: "* @org/platform\n*.js @org/frontend\n",
+			path:    "web/app.js",
+			want:    []string{"org/frontend"},
+		},
+		{
+			name:    "earlier rule applies when the later one doesn't match",
+			content: "* @org/platform\n*.js @org/frontend\n",
+			path:    "web/app.go",
+			want:    []string{"org/platform"},
+		},
+		{
+			name:    "rule without owners un-assigns",
+			content: "* @org/platform\n/build/\n",
+			path:    "build/out.js",
+			want:    nil,
+		},
+		{
+			name:    "directory pattern",
+			content: "* @org/platform\n/docs/ @org/docs docs@example.com\n",
+			path:    "docs",
+			isDir:   true,
+			want:    []string{"org/docs", "docs@example.com"},
+		},
+		{
+			name:    "owners from different sections combine",
+			content: "* @org/platform\n\n[Documentation] @org/docs\n/docs/\n",
+			path:    "docs/guide.md",
+			want:    []string{"org/platform", "org/docs"},
+		},
+		{
+			name:    "last match wins within a section",
+			content: "[Backend][2] @org/backend\n/api/\n/api/internal/ @org/core\n",
+			path:    "api/internal/auth.go",
+			want:    []string{"org/core"},
+		},
+		{
+			name:    "optional section with default owners",
+			content: "^[Ops] @ops-lead\n/deploy/\n",
+			path:    "deploy/prod.yml",
+			want:    []string{"ops-lead"},
+		},
+		{
+			name:    "comments and stray text are ignored",
+			content: "# owners\n*.go @org/go # gophers\n",
+			path:    "main.go",
+			want:    []string{"org/go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseCodeOwnersFile(tt.content).Owners(tt.path, tt.isDir)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeOwnersSections(t *testing.T) {
+	file := ParseCodeOwnersFile("* @org/platform\n[Docs] @org/docs\n/docs/\n/docs/api/ @org/api\n")
+
+	want := []CodeOwnersRule{
+		{Pattern: "*", Owners: []string{"org/platform"}, Section: "", Line: 1},
+		{Pattern: "/docs/", Owners: []string{"org/docs"}, Section: "Docs", Line: 3},
+		{Pattern: "/docs/api/", Owners: []string{"org/api"}, Section: "Docs", Line: 4},
+	}
+	if len(file.Rules) != len(want) {
+		t.Fatalf("got %d rules, want %d", len(file.Rules), len(want))
+	}
+	for i, rule := range file.Rules {
+		rule.regex = nil
+		if !reflect.DeepEqual(rule, want[i]) {
+			t.Errorf("rule %d = %+v, want %+v", i, rule, want[i])
+		}
+	}
+
+	if got := file.RootOwners(); !reflect.DeepEqual(got, []string{"org/platform"}) {
+		t.Errorf("RootOwners = %v, want [org/platform]", got)
+	}
+	if got := file.AllOwners(); !reflect.DeepEqual(got, []string{"org/platform", "org/docs", "org/api"}) {
+		t.Errorf("AllOwners = %v", got)
+	}
+}
+
+func TestCodeOwnersUnownedPaths(t *testing.T) {
+	file := ParseCodeOwnersFile("/src/ @org/backend\n/README.md @org/docs\n")
+	files := []string{"README.md", "build", "build/out.js", "build/assets/logo.png", "scripts/deploy.sh", "src", "src/main.go"}
+	dirs := map[string]bool{"build": true, "build/assets": true, "src": true}
+
+	// An unowned directory stands in for what's inside it
+	want := []string{"build", "scripts/deploy.sh"}
+	if got := file.UnownedPaths(files, dirs); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnownedPaths = %v, want %v", got, want)
+	}
+}
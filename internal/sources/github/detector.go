@@ -1,171 +1,151 @@
 package github
 
-import "strings"
-
-type Detector struct{}
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// Detects CI/CD, tests, platforms and deployable units from a repo's files using detection rules
+type Detector struct {
+	rules []*Rule // by priority, highest first
+}
 
+// Creates a detector with the built-in rules
 func NewDetector() *Detector {
-	return &Detector{}
-}
-
-// CI/CD platform indicators (root level only, PERHAPS rethink because what if its not root?)
-// For files, match exactly; for directories, match prefix
-var cicdFiles = map[string]string{
-	".circleci":               "CircleCI",
-	".github/workflows":       "GitHub Actions",
-	".github":                 "GitHub Actions",
-	"bitbucket-pipelines.yml": "Bitbucket Pipelines",
-	".gitlab-ci.yml":          "GitLab CI",
-	"Jenkinsfile":             "Jenkins",
-	".travis.yml":             "Travis CI",
-	"azure-pipelines.yml":     "Azure Pipelines",
-}
-
-// Test directory patterns
-var testDirs = []string{
-	"test",
-	"tests",
-	"__tests__",
-	"spec",
-	"test_suite",
-	"testing",
-}
-
-// EKS platform indicators (if found, skip the repo)
-var eksIndicators = []string{
-	"k8s",
-	"kubernetes",
-	".kube",
-	"helm",
-	"Chart.yaml",
-	"kustomization.yaml",
-	"kustomization.yml",
-}
-
-// Platform-specific files
-var ecsIndicators = []string{
-	"ecs-task-definition.json",
-	"ecs-service.json",
-	"Dockerfile",
-}
-
-var lambdaIndicators = []string{
-	"serverless.yml",
-	"serverless.yaml",
-	"template.yaml",
-	"template.yml", // SAM
-	"lambda",       // directory
-	"functions",    // directory
-}
-
-var beanstalkIndicators = []string{
-	".ebextensions/",
-	"Procfile",
-	".elasticbeanstalk",
-}
-
-// Checks for CI/CD configuration files at root level
-func (d *Detector) DetectCICD(files []string) (bool, string) {
-	for _, file := range files {
-		for pattern, platform := range cicdFiles {
-			if file == pattern || strings.HasPrefix(file, pattern) {
-				return true, platform
-			}
+	return NewDetectorWithRules(DefaultRules())
+}
 
-			// Directory match (for .circleci, .github)
-			// Files list contains directory names without trailing slash
-			if strings.HasPrefix(pattern, file+"/") || file == strings.TrimSuffix(pattern, "/") {
-				return true, platform
-			}
-		}
-	}
-	return false, ""
+// Creates a detector with the given rules, e.g. from LoadRules
+func NewDetectorWithRules(rules []*Rule) *Detector {
+	return &Detector{rules: sortRules(rules)}
 }
 
-// Checks for test directories or files
-func (d *Detector) DetectTests(files []string) (bool, string) {
-	for _, file := range files {
-		for _, testDir := range testDirs {
-			// Directory match (without trailing slash in files list)
-			if file == testDir {
-				return true, "detected test directory"
-			}
+// Returns the first matching rule of a kind, by priority
+func (d *Detector) firstMatch(kind string, files []string, contents map[string]string) *Rule {
+	for _, rule := range d.rules {
+		if rule.Detects == kind && rule.matches(files, contents) {
+			return rule
 		}
 	}
-	// Check for common test file patterns
-	testPatterns := []string{
-		"_test.go",
-		".spec.js",
-		".test.js",
-		".spec.ts",
-		".test.ts",
-		"Test.java",
-		"test_",
-	}
+	return nil
+}
 
-	for _, file := range files {
-		for _, pattern := range testPatterns {
-			if strings.Contains(file, pattern) {
-				return true, "detected test files"
-			}
+// Returns the values of every matching rule of a kind, by priority, each once
+func (d *Detector) allMatches(kind string, files []string, contents map[string]string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, rule := range d.rules {
+		if rule.Detects == kind && !seen[rule.Value] && rule.matches(files, contents) {
+			seen[rule.Value] = true
+			values = append(values, rule.Value)
 		}
 	}
+	return values
+}
 
-	return false, ""
+// A CI/CD system found in a repo and the paths that show it
+type CICDSystem struct {
+	Name     string
+	Evidence []string
 }
 
-// Checks if the repo is an EKS application
-func (d *Detector) IsEKS(files []string) bool {
-	for _, file := range files {
-		for _, indicator := range eksIndicators {
-			// Check both exact match and directory name
-			if file == indicator || file == strings.TrimSuffix(indicator, "/") {
-				return true
+// Lists every CI/CD system configured in the repo, in rule order. With recursive scanning these also match nested paths
+func (d *Detector) DetectCICD(files []string, contents map[string]string) []CICDSystem {
+	var systems []CICDSystem
+	index := make(map[string]int) // system name -> position in systems, when several rules report one system
+
+	for _, rule := range d.rules {
+		if rule.Detects != RuleCICD {
+			continue
+		}
+		evidence := rule.evidence(files, contents)
+		if len(evidence) == 0 {
+			continue
+		}
+
+		if i, ok := index[rule.Value]; ok {
+			for _, file := range evidence {
+				if !containsPath(systems[i].Evidence, file) {
+					systems[i].Evidence = append(systems[i].Evidence, file)
+				}
 			}
+			continue
 		}
+		index[rule.Value] = len(systems)
+		systems = append(systems, CICDSystem{Name: rule.Value, Evidence: evidence})
 	}
-	return false
+
+	return systems
 }
 
-// Determines the deployment platform
-func (d *Detector) DetectPlatform(files []string) string {
-	platforms := []string{}
+// Checks for test directories or files
+func (d *Detector) DetectTests(files []string, contents map[string]string) (bool, string) {
+	if rule := d.firstMatch(RuleTests, files, contents); rule != nil {
+		return true, rule.Value
+	}
+	return false, ""
+}
 
-	// Check ECS
-	for _, file := range files {
-		for _, indicator := range ecsIndicators {
-			if file == indicator {
-				platforms = append(platforms, "ECS")
-				break
-			}
-		}
+// Checks if the repo should be skipped (EKS applications by default), and why
+func (d *Detector) IsExcluded(files []string, contents map[string]string) (bool, string) {
+	if rule := d.firstMatch(RuleExclude, files, contents); rule != nil {
+		return true, rule.Value
 	}
-	// Check Lambda
-	for _, file := range files {
-		for _, indicator := range lambdaIndicators {
-			if file == indicator {
-				platforms = append(platforms, "Lambda")
-				break
+	return false, ""
+}
+
+// Returns the paths that make the repo a Kubernetes (EKS) app, empty if it isn't one
+func (d *Detector) KubernetesEvidence(files []string, contents map[string]string) []string {
+	for _, rule := range d.rules {
+		if rule.Detects == RuleExclude && rule.Value == EKSExclusion {
+			if evidence := rule.evidence(files, contents); len(evidence) > 0 {
+				return evidence
 			}
 		}
 	}
+	return nil
+}
 
-	// Check Elastic Beanstalk
-	for _, file := range files {
-		for _, indicator := range beanstalkIndicators {
-			if file == indicator {
-				platforms = append(platforms, "Elastic Beanstalk")
-				break
+// Lists the files content rules need to read, shallowest first, capped at maxContentFiles
+func (d *Detector) ContentFiles(files []string) []string {
+	seen := make(map[string]bool)
+	var needed []string
+	for _, rule := range d.rules {
+		if rule.Match.Content == nil {
+			continue
+		}
+		for _, file := range files {
+			if !seen[file] && rule.Match.Content.covers(file) {
+				seen[file] = true
+				needed = append(needed, file)
 			}
 		}
 	}
 
-	if len(platforms) == 0 {
-		return "Unknown"
+	sort.SliceStable(needed, func(i, j int) bool {
+		return strings.Count(needed[i], "/") < strings.Count(needed[j], "/")
+	})
+	if len(needed) > maxContentFiles {
+		needed = needed[:maxContentFiles]
 	}
+	return needed
+}
+
+// Determines the deployment platform, comma-separated if several. See DetectDeployment for the details
+func (d *Detector) DetectPlatform(files []string, contents map[string]string) string {
+	return strings.Join(d.DetectDeployment(files, contents).Platforms, ", ")
+}
 
-	// Return comma-separated if multiple platforms detected
-	return strings.Join(platforms, ", ")
+// Reports whether a path is, or lives under, the indicator at any depth.
+// "Dockerfile" matches "Dockerfile" and "services/api/Dockerfile"; ".ebextensions/" matches ".ebextensions/app.config";
+// ".github/workflows" matches ".github/workflows/deploy.yml". Whole segments only, so "latest" never matches "test".
+func matchesPath(file, indicator string) bool {
+	indicator = strings.Trim(indicator, "/")
+	if indicator == "" {
+		return false
+	}
+	return strings.Contains("/"+file+"/", "/"+indicator+"/")
 }
 
 // Checks if CODEOWNERS file exists
@@ -187,46 +167,29 @@ func (d *Detector) DetectCodeOwners(files []string) bool {
 	return false
 }
 
-// TODO: CODEOWNERS file is not being recognized unless its situated at the root
-// Extracts team/owner information from codeowners content
+// Extracts team/owner information from codeowners content, every owner once in order of appearance
 func (d *Detector) ParseCodeOwners(content string) []string {
-	var owners []string
-	ownerSet := make(map[string]bool) // Track unique owners
-	lines := strings.Split(content, "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// Skip comments and empty lines
-		if line == "" || strings.HasPrefix(line, "#") {
+	return ParseCodeOwnersFile(content).AllOwners()
+}
+
+// Lists the subdirectories that hold their own deployment config (root excluded), sorted
+func (d *Detector) DeployableDirs(files []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, file := range files {
+		dir := path.Dir(file)
+		if dir == "." || seen[dir] {
 			continue
 		}
-
-		// CODEOWNERS format: path @owner1 @owner2 email@example.com
-		parts := strings.Fields(line)
-		if len(parts) > 1 {
-			// Skip the first part (it's the file pattern like * or /src/*)
-			for _, part := range parts[1:] {
-				var owner string
-
-				if strings.HasPrefix(part, "@") {
-					// GitHub username: @username or @org/team
-					owner = strings.TrimPrefix(part, "@")
-				} else if strings.Contains(part, "@") {
-					// Email address: user@domain.com
-					owner = part
-				} else {
-					// Skip non-owner parts (like file patterns)
-					continue
-				}
-
-				// Add to set (deduplicates automatically)
-				if !ownerSet[owner] {
-					ownerSet[owner] = true
-					owners = append(owners, owner)
-				}
+		for _, rule := range d.rules {
+			// Only the file name counts, so .ebextensions/app.config doesn't make .ebextensions a unit
+			if rule.Detects == RuleDeploy && rule.matchesFile(path.Base(file)) {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+				break
 			}
 		}
 	}
-
-	return owners
+	sort.Strings(dirs)
+	return dirs
 }
@@ -0,0 +1,214 @@
+package github
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Most manifests fetched per repository, shallowest first, to bound API calls on large monorepos
+const maxManifests = 10
+
+// Manifest file names that can declare a test framework
+var manifestFiles = map[string]bool{
+	"package.json":     true,
+	"pyproject.toml":   true,
+	"setup.cfg":        true,
+	"setup.py":         true,
+	"tox.ini":          true,
+	"pytest.ini":       true,
+	"Pipfile":          true,
+	"go.mod":           true,
+	"pom.xml":          true,
+	"build.gradle":     true,
+	"build.gradle.kts": true,
+	"Gemfile":          true,
+	// requirements*.txt handled in isManifest
+}
+
+// package.json dependencies that identify a JavaScript test framework
+var jsTestPackages = map[string]string{
+	"jest":             "jest",
+	"mocha":            "mocha",
+	"vitest":           "vitest",
+	"jasmine":          "jasmine",
+	"ava":              "ava",
+	"karma":            "karma",
+	"cypress":          "cypress",
+	"@playwright/test": "playwright",
+}
+
+// Content signals per manifest kind, in the order frameworks are reported
+var (
+	pytestPattern   = regexp.MustCompile(`(?i)\bpytest\b`)
+	unittestPattern = regexp.MustCompile(`\bunittest\b`)
+	junitPattern    = regexp.MustCompile(`(?i)junit|useJUnitPlatform`)
+	testngPattern   = regexp.MustCompile(`(?i)\btestng\b`)
+	spockPattern    = regexp.MustCompile(`(?i)spock-core`)
+	rspecPattern    = regexp.MustCompile(`\brspec\b`)
+	minitestPattern = regexp.MustCompile(`\bminitest\b`)
+	ginkgoPattern   = regexp.MustCompile(`github\.com/onsi/ginkgo`)
+)
+
+// Reports whether a path is a manifest worth reading for test frameworks
+func isManifest(file string) bool {
+	base := path.Base(file)
+	if manifestFiles[base] {
+		return true
+	}
+	// requirements.txt, requirements-dev.txt, requirements-test.txt
+	return strings.HasPrefix(base, "requirements") && strings.HasSuffix(base, ".txt")
+}
+
+// Returns the manifest paths in files, shallowest first, capped at maxManifests
+func (d *Detector) FindManifests(files []string) []string {
+	var manifests []string
+	for _, file := range files {
+		if isManifest(file) {
+			manifests = append(manifests, file)
+		}
+	}
+
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return strings.Count(manifests[i], "/") < strings.Count(manifests[j], "/")
+	})
+	if len(manifests) > maxManifests {
+		manifests = manifests[:maxManifests]
+	}
+	return manifests
+}
+
+// Identifies test frameworks from manifest contents (path -> content), the file list and test_framework rules.
+// Only names frameworks: whether the repo has tests at all is DetectTests' call.
+func (d *Detector) DetectTestFrameworks(manifests map[string]string, files []string) []string {
+	found := make(map[string]bool)
+	var frameworks []string
+	add := func(name string) {
+		if !found[name] {
+			found[name] = true
+			frameworks = append(frameworks, name)
+		}
+	}
+
+	// Deterministic order regardless of map iteration
+	paths := make([]string, 0, len(manifests))
+	for p := range manifests {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		if !isManifest(p) {
+			continue // fetched for a content rule
+		}
+		content := manifests[p]
+		base := path.Base(p)
+
+		switch {
+		case base == "package.json":
+			for _, name := range jsFrameworks(content) {
+				add(name)
+			}
+
+		case base == "go.mod":
+			if ginkgoPattern.MatchString(content) {
+				add("ginkgo")
+			}
+
+		case base == "pom.xml", strings.HasPrefix(base, "build.gradle"):
+			if junitPattern.MatchString(content) {
+				add("JUnit")
+			}
+			if testngPattern.MatchString(content) {
+				add("TestNG")
+			}
+			if spockPattern.MatchString(content) {
+				add("Spock")
+			}
+
+		case base == "Gemfile":
+			if rspecPattern.MatchString(content) {
+				add("RSpec")
+			}
+			if minitestPattern.MatchString(content) {
+				add("Minitest")
+			}
+
+		default: // Python manifests
+			if base == "pytest.ini" || pytestPattern.MatchString(content) {
+				add("pytest")
+			}
+			if unittestPattern.MatchString(content) {
+				add("unittest")
+			}
+		}
+	}
+
+	// Framework marker files that need no content
+	for _, file := range files {
+		switch path.Base(file) {
+		case "conftest.py", "pytest.ini":
+			add("pytest")
+		case ".rspec":
+			add("RSpec")
+		case "jest.config.js", "jest.config.ts":
+			add("jest")
+		case "vitest.config.ts", "vitest.config.js":
+			add("vitest")
+		case ".mocharc.json", ".mocharc.yml", ".mocharc.js":
+			add("mocha")
+		}
+		// go test needs no manifest entry, only test files
+		if strings.HasSuffix(file, "_test.go") {
+			add("go test")
+		}
+	}
+
+	// Conventions from the rules file
+	for _, name := range d.allMatches(RuleTestFramework, files, manifests) {
+		add(name)
+	}
+
+	return frameworks
+}
+
+// Reads test frameworks out of a package.json's dependencies and test script
+func jsFrameworks(content string) []string {
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+		Scripts         map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return nil
+	}
+
+	var frameworks []string
+	seen := make(map[string]bool)
+	for _, deps := range []map[string]string{pkg.DevDependencies, pkg.Dependencies} {
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if framework, ok := jsTestPackages[name]; ok && !seen[framework] {
+				seen[framework] = true
+				frameworks = append(frameworks, framework)
+			}
+		}
+	}
+
+	// "test": "jest --coverage" with jest installed globally or via npx
+	script := pkg.Scripts["test"]
+	for _, pkgName := range []string{"jest", "mocha", "vitest", "jasmine", "ava"} {
+		if strings.Contains(script, pkgName) && !seen[pkgName] {
+			seen[pkgName] = true
+			frameworks = append(frameworks, pkgName)
+		}
+	}
+
+	return frameworks
+}
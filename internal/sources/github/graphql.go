@@ -0,0 +1,382 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// Ways of fetching repository metadata
+const (
+	BackendREST    = "rest"    // one ListByOrg page per 100 repos, then tree, commit and contents calls per repo
+	BackendGraphQL = "graphql" // ListByOrg-equivalent pages, then one query per graphqlBatchSize repos
+)
+
+const (
+	graphqlPageSize  = 100 // repos per listing query
+	graphqlBatchSize = 25  // repos per detail query; trees and blobs make each repo costly
+)
+
+// CODEOWNERS locations fetched with each repo, in GitHub's order of precedence
+var codeOwnersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Lists the org's repos like ListRepositories, fetching root entries, CI config directories, last commit and
+// CODEOWNERS for many repos per query. CODEOWNERS is kept for GetFileContent so it costs no extra call.
+// Repos a query can't return are fetched over REST instead.
+func (c *Client) listRepositoriesGraphQL(ctx context.Context, excludeArchived bool, unchanged func(*Repository) bool) ([]*Repository, error) {
+	var allRepos []*Repository
+	var cursor *string
+	for {
+		var page struct {
+			Organization *struct {
+				Repositories struct {
+					Nodes []struct {
+						Name             string  `json:"name"`
+						IsArchived       bool    `json:"isArchived"`
+						URL              string  `json:"url"`
+						PushedAt         *string `json:"pushedAt"`
+						DefaultBranchRef *struct {
+							Name string `json:"name"`
+						} `json:"defaultBranchRef"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"repositories"`
+			} `json:"organization"`
+		}
+
+		// Same order as the REST listing: newest first
+		err := c.graphql(ctx, `query($org: String!, $first: Int!, $after: String) {
+  organization(login: $org) {
+    repositories(first: $first, after: $after, orderBy: {field: CREATED_AT, direction: DESC}) {
+      nodes { name isArchived url pushedAt defaultBranchRef { name } }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`, map[string]any{"org": c.org, "first": graphqlPageSize, "after": cursor}, &page)
+		if err != nil {
+			return nil, fmt.Errorf("listRepositories: failed to list repositories: %w", err)
+		}
+		if page.Organization == nil {
+			return nil, fmt.Errorf("listRepositories: organization %s not found", c.org)
+		}
+
+		for _, node := range page.Organization.Repositories.Nodes {
+			if excludeArchived && node.IsArchived {
+				continue
+			}
+			repo := &Repository{
+				Name:       node.Name,
+				IsArchived: node.IsArchived,
+				HTMLURL:    node.URL,
+			}
+			if node.DefaultBranchRef != nil {
+				repo.DefaultBranch = node.DefaultBranchRef.Name
+			}
+			if node.PushedAt != nil {
+				if pushed, err := time.Parse(time.RFC3339, *node.PushedAt); err == nil {
+					repo.PushedAt = pushed.UTC().Format(time.RFC3339)
+				}
+			}
+			allRepos = append(allRepos, repo)
+		}
+
+		info := page.Organization.Repositories.PageInfo
+		if !info.HasNextPage {
+			break
+		}
+		cursor = &info.EndCursor
+	}
+
+	// Batch the repos that need fetching; unchanged ones keep their listing metadata only
+	var pending []*Repository
+	for _, repo := range allRepos {
+		if unchanged != nil && unchanged(repo) {
+			repo.Unchanged = true
+			continue
+		}
+		pending = append(pending, repo)
+	}
+
+	batches := (len(pending) + graphqlBatchSize - 1) / graphqlBatchSize
+	batchErrs := make([]error, batches)
+	err := runPool(ctx, batches, c.workers, func(ctx context.Context, i int) {
+		end := min((i+1)*graphqlBatchSize, len(pending))
+		batchErrs[i] = c.fetchRepositoriesGraphQL(ctx, pending[i*graphqlBatchSize:end])
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listRepositories: %w", err)
+	}
+	for _, err := range batchErrs {
+		if err != nil {
+			return nil, fmt.Errorf("listRepositories: %w", err)
+		}
+	}
+
+	return allRepos, nil
+}
+
+// Tree entry as GraphQL returns it
+type graphqlEntry struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type graphqlTree struct {
+	Entries []graphqlEntry `json:"entries"`
+}
+
+type graphqlBlob struct {
+	Text        *string `json:"text"` // nil for binary blobs
+	IsTruncated bool    `json:"isTruncated"`
+}
+
+// One repo of a detail query
+type graphqlRepository struct {
+	DefaultBranchRef *struct {
+		Target struct {
+			Committer *struct {
+				Name *string `json:"name"`
+				Date *string `json:"date"`
+			} `json:"committer"`
+			Tree *graphqlTree `json:"tree"`
+		} `json:"target"`
+	} `json:"defaultBranchRef"`
+	GitHub    *graphqlTree `json:"github"`
+	Workflows *graphqlTree `json:"workflows"`
+	CircleCI  *graphqlTree `json:"circleci"`
+	Owners0   *graphqlBlob `json:"owners0"`
+	Owners1   *graphqlBlob `json:"owners1"`
+	Owners2   *graphqlBlob `json:"owners2"`
+}
+
+// Fields fetched per repo. The aliases match graphqlRepository; expandedDirs and codeOwnersLocations, in order.
+const graphqlRepositoryFields = `
+    defaultBranchRef { target { ... on Commit { committer { name date } tree { entries { name type } } } } }
+    github: object(expression: "HEAD:.github") { ... on Tree { entries { name type } } }
+    workflows: object(expression: "HEAD:.github/workflows") { ... on Tree { entries { name type } } }
+    circleci: object(expression: "HEAD:.circleci") { ... on Tree { entries { name type } } }
+    owners0: object(expression: "HEAD:.github/CODEOWNERS") { ... on Blob { text isTruncated } }
+    owners1: object(expression: "HEAD:CODEOWNERS") { ... on Blob { text isTruncated } }
+    owners2: object(expression: "HEAD:docs/CODEOWNERS") { ... on Blob { text isTruncated } }`
+
+// Fills in a batch of repos with one query, falling back to REST for any the query didn't return.
+// Fails only when the batch is still rate limited after maxRateLimitRetries queries.
+func (c *Client) fetchRepositoriesGraphQL(ctx context.Context, repos []*Repository) error {
+	var query strings.Builder
+	vars := map[string]any{"org": c.org}
+	query.WriteString("query($org: String!")
+	for i, repo := range repos {
+		fmt.Fprintf(&query, ", $n%d: String!", i)
+		vars[fmt.Sprintf("n%d", i)] = repo.Name
+	}
+	query.WriteString(") {\n")
+	for i := range repos {
+		fmt.Fprintf(&query, "  r%d: repository(owner: $org, name: $n%d) {%s\n  }\n", i, i, graphqlRepositoryFields)
+	}
+	query.WriteString("}")
+
+	var data map[string]*graphqlRepository
+	for attempt := 1; ; attempt++ {
+		data = nil
+		err := c.graphql(ctx, query.String(), vars, &data)
+		if errors.Is(err, errGraphQLRateLimited) && ctx.Err() == nil {
+			// Still limited after the transport's backoff: falling back would spend several REST calls per repo
+			if attempt >= maxRateLimitRetries {
+				return fmt.Errorf("fetchRepositoriesGraphQL: batch of %d repos still rate limited after %d attempts: %w", len(repos), attempt, err)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: GraphQL batch of %d repos still rate limited, retrying (attempt %d/%d)\n", len(repos), attempt, maxRateLimitRetries)
+			continue
+		}
+		// Partial data still comes back with per-repo errors; only a failed request loses the batch
+		if err != nil && data == nil {
+			fmt.Fprintf(os.Stderr, "Warning: GraphQL batch of %d repos failed, using REST: %v\n", len(repos), err)
+		}
+		break
+	}
+
+	for i, repo := range repos {
+		details := data[fmt.Sprintf("r%d", i)]
+		if details == nil {
+			c.fetchRepository(ctx, repo)
+			continue
+		}
+		c.applyGraphQLDetails(ctx, repo, details)
+	}
+	return nil
+}
+
+// Copies a detail query's answer onto the repo, shaped exactly like the REST fetch
+func (c *Client) applyGraphQLDetails(ctx context.Context, repo *Repository, details *graphqlRepository) {
+	var root *graphqlTree
+	if details.DefaultBranchRef != nil {
+		root = details.DefaultBranchRef.Target.Tree
+	}
+
+	switch {
+	case c.recursive:
+		// GraphQL trees are one level deep; recursive scans keep using the REST tree walk
+		files, dirs, partial, err := c.getFileTree(ctx, repo.Name, repo.DefaultBranch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get file tree for %s: %v\n", repo.Name, err)
+			files = []string{}
+		}
+		repo.Files, repo.Dirs, repo.PartialTree = files, dirs, partial
+	case root == nil:
+		fmt.Fprintf(os.Stderr, "Warning: failed to get file tree for %s: repository is empty\n", repo.Name)
+		repo.Files = []string{}
+	default:
+		files, dirs := collectTreeEntries(treeEntries(root), "", 1)
+		subtrees := map[string]*graphqlTree{
+			".github":           details.GitHub,
+			".github/workflows": details.Workflows,
+			".circleci":         details.CircleCI,
+		}
+		for _, dir := range expandedDirs {
+			if subtrees[dir] == nil {
+				continue
+			}
+			levelFiles, levelDirs := collectTreeEntries(treeEntries(subtrees[dir]), dir, strings.Count(dir, "/")+2)
+			files = append(files, levelFiles...)
+			dirs = append(dirs, levelDirs...)
+		}
+		repo.Files, repo.Dirs = files, dirs
+	}
+
+	if details.DefaultBranchRef != nil && details.DefaultBranchRef.Target.Committer != nil {
+		committer := details.DefaultBranchRef.Target.Committer
+		repo.LastCommitter = "Unknown"
+		if committer.Name != nil {
+			repo.LastCommitter = *committer.Name
+		}
+		if committer.Date != nil {
+			if date, err := time.Parse(time.RFC3339, *committer.Date); err == nil {
+				repo.LastCommitDate = date.UTC().Format("2006-01-02") // same day as the REST fetch, which reads UTC
+			}
+		}
+	}
+
+	for i, blob := range []*graphqlBlob{details.Owners0, details.Owners1, details.Owners2} {
+		switch {
+		case blob == nil:
+			c.prefetch(repo.Name, codeOwnersLocations[i], nil)
+		case blob.Text != nil && !blob.IsTruncated:
+			c.prefetch(repo.Name, codeOwnersLocations[i], blob.Text)
+		}
+		// Truncated or binary: left for GetFileContent to fetch
+	}
+}
+
+// Converts GraphQL tree entries to the REST shape collectTreeEntries reads
+func treeEntries(tree *graphqlTree) []*github.TreeEntry {
+	entries := make([]*github.TreeEntry, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		entries = append(entries, &github.TreeEntry{
+			Path: github.String(entry.Name),
+			Type: github.String(entry.Type),
+		})
+	}
+	return entries
+}
+
+// Files fetched alongside repo metadata, keyed by repo and path; a nil content means the file doesn't exist
+type prefetchedFiles struct {
+	mu    sync.Mutex
+	files map[string]*string
+}
+
+// Records a file's content (nil for missing) for GetFileContent
+func (c *Client) prefetch(repoName, path string, content *string) {
+	c.prefetched.mu.Lock()
+	defer c.prefetched.mu.Unlock()
+	if c.prefetched.files == nil {
+		c.prefetched.files = make(map[string]*string)
+	}
+	c.prefetched.files[repoName+"/"+path] = content
+}
+
+// Returns a prefetched file: ok is false when it wasn't prefetched, found false when it doesn't exist
+func (c *Client) prefetchedFile(repoName, path string) (content string, found, ok bool) {
+	c.prefetched.mu.Lock()
+	defer c.prefetched.mu.Unlock()
+	value, ok := c.prefetched.files[repoName+"/"+path]
+	if !ok {
+		return "", false, false
+	}
+	if value == nil {
+		return "", false, true
+	}
+	return *value, true, true
+}
+
+// Error GetFileContent returns for a prefetched file that doesn't exist, shaped like the REST 404
+func notFoundError(path string) error {
+	return &github.ErrorResponse{
+		Response: &http.Response{StatusCode: http.StatusNotFound},
+		Message:  "Not Found: " + path,
+	}
+}
+
+// Error type GraphQL reports when the query's point budget is used up. It arrives as a 200 with no data.
+const graphqlRateLimited = "RATE_LIMITED"
+
+// Returned by graphql when the rate limit outlasted the transport's retries
+var errGraphQLRateLimited = errors.New("GraphQL rate limit exceeded")
+
+// Runs a GraphQL query and decodes its data into out.
+// GraphQL reports most failures (missing repos, rate limits) in an errors list next to partial data;
+// those come back as an error with out still filled in as far as it got.
+func (c *Client) graphql(ctx context.Context, query string, variables map[string]any, out any) error {
+	req, err := c.client.NewRequest(http.MethodPost, c.graphqlURL(), map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("[graphql] error: %w", err)
+	}
+
+	var resp struct {
+		Data   any `json:"data"`
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	resp.Data = out
+	if _, err := c.client.Do(ctx, req, &resp); err != nil {
+		return fmt.Errorf("[graphql] error: %w", err)
+	}
+
+	if len(resp.Errors) > 0 {
+		messages := make([]string, 0, len(resp.Errors))
+		for _, gqlErr := range resp.Errors {
+			if gqlErr.Type == graphqlRateLimited {
+				return fmt.Errorf("[graphql] error: %w: %s", errGraphQLRateLimited, gqlErr.Message)
+			}
+			messages = append(messages, gqlErr.Message)
+		}
+		return fmt.Errorf("[graphql] error: %s", strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+// Returns the GraphQL endpoint next to the REST base URL: api.github.com/graphql, or <host>/api/graphql on GHES
+func (c *Client) graphqlURL() string {
+	base := c.client.BaseURL
+	if strings.HasSuffix(base.Path, "/api/v3/") {
+		endpoint := *base
+		endpoint.Path = strings.TrimSuffix(base.Path, "v3/") + "graphql"
+		return endpoint.String()
+	}
+	return base.ResolveReference(&url.URL{Path: "graphql"}).String()
+}
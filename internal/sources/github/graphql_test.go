@@ -0,0 +1,36 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+)
+
+func TestFetchRepositoriesGraphQLGivesUpWhileRateLimited(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.Write([]byte(`{"data":null,"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded"}]}`))
+	}))
+	defer server.Close()
+
+	gh := github.NewClient(&http.Client{Transport: newRateLimitTransport(nil)})
+	gh.BaseURL, _ = url.Parse(server.URL + "/")
+	client := &Client{client: gh, org: "org", workers: 1}
+
+	err := client.fetchRepositoriesGraphQL(context.Background(), []*Repository{{Name: "api"}})
+	if !errors.Is(err, errGraphQLRateLimited) {
+		t.Fatalf("err = %v, want the rate limit after the last attempt", err)
+	}
+	// Each query is retried by the transport before the batch tries again
+	if got, want := hits.Load(), int32(maxRateLimitRetries*maxRateLimitRetries); got != want {
+		t.Errorf("server got %d requests, want %d", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package github
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Returns the collection settings that shape what a repo's rows contain. Saved with snapshots so an
+// incremental run only reuses a baseline collected the same way.
+func (o Options) Settings() map[string]string {
+	rules := "default"
+	if o.Rules != nil {
+		data, _ := json.Marshal(o.Rules)
+		sum := sha256.Sum256(data)
+		rules = hex.EncodeToString(sum[:8])
+	}
+	maxDepth := o.MaxDepth
+	if maxDepth < 1 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	return map[string]string{
+		"github.recursive":           strconv.FormatBool(o.Recursive),
+		"github.max_depth":           strconv.Itoa(maxDepth),
+		"github.rules":               rules,
+		"github.resolve_owners":      strconv.FormatBool(o.ResolveOwners),
+		"github.validate_codeowners": strconv.FormatBool(o.ValidateCodeOwners),
+		"github.include_eks":         strconv.FormatBool(o.IncludeEKS),
+		"github.deploy_units":        strconv.FormatBool(o.DeployUnits),
+	}
+}
+
+// Returns the baseline's GitHub rows by repo, or nil when there is no baseline or it was collected
+// with other settings (mixing detector settings would make the rows disagree with each other)
+func (ds *DataSource) usableBaseline() map[string][]*inventory.ResourceInfo {
+	if ds.opts.Baseline == nil {
+		return nil
+	}
+	if ds.opts.BaselineSettings == nil {
+		fmt.Fprintf(os.Stderr, "Warning: baseline records no collection settings (saved by an older version, or not a --format json file); refreshing every repo\n")
+		return nil
+	}
+	if diff := settingsDiff(ds.opts.BaselineSettings, ds.opts.Settings()); diff != "" {
+		fmt.Fprintf(os.Stderr, "Warning: baseline was collected with different settings (%s); refreshing every repo\n", diff)
+		return nil
+	}
+	return baselineByRepo(ds.opts.Baseline)
+}
+
+// Describes how the baseline's settings differ from this run's, "" if they match
+func settingsDiff(baseline, current map[string]string) string {
+	var changes []string
+	for key, value := range current {
+		if baseline[key] != value {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", strings.TrimPrefix(key, "github."), baseline[key], value))
+		}
+	}
+	sort.Strings(changes)
+	return strings.Join(changes, ", ")
+}
+
+// Groups a baseline inventory's GitHub rows by repo, each repo's own row first and then its units.
+// Returns nil without a baseline.
+func baselineByRepo(baseline *inventory.Inventory) map[string][]*inventory.ResourceInfo {
+	if baseline == nil {
+		return nil
+	}
+
+	byRepo := make(map[string][]*inventory.ResourceInfo)
+	for _, res := range baseline.Resources {
+		if res.GitHubRepo == "" {
+			continue
+		}
+		if res.UnitPath == "" {
+			byRepo[res.GitHubRepo] = append([]*inventory.ResourceInfo{res}, byRepo[res.GitHubRepo]...)
+		} else {
+			byRepo[res.GitHubRepo] = append(byRepo[res.GitHubRepo], res)
+		}
+	}
+	return byRepo
+}
+
+// Reports whether the repo's last push is the one its baseline row was analyzed at.
+// Rows from before pushed_at was recorded never match, so those repos are refreshed.
+func isUnchanged(rows []*inventory.ResourceInfo, repo *Repository) bool {
+	if len(rows) == 0 || rows[0].UnitPath != "" {
+		return false
+	}
+	return rows[0].PushedAt != "" && rows[0].PushedAt == repo.PushedAt
+}
+
+// Copies a repo's baseline rows into this run. Listing metadata (archived, URL) is taken from the
+// current listing since it can change without a push, and so are the owner checks: people leave the
+// org and teams are deleted without anyone pushing.
+func (ds *DataSource) carryForward(ctx context.Context, rows []*inventory.ResourceInfo, repo *Repository) []*inventory.ResourceInfo {
+	carried := make([]*inventory.ResourceInfo, 0, len(rows))
+	for _, row := range rows {
+		res := *row
+		res.IsArchived = repo.IsArchived
+		res.RepoURL = repo.HTMLURL
+		carried = append(carried, &res)
+	}
+
+	info := carried[0]
+	info.CodeOwnersErrors = nil
+	info.CodeOwnersChecked = false
+	info.OwnerDetails = nil
+	ds.checkOwners(ctx, info, repo)
+	for _, unit := range carried[1:] {
+		unit.OwnerDetails = ownerDetailsFor(info.OwnerDetails, unit.CodeOwners)
+	}
+	return carried
+}
@@ -0,0 +1,59 @@
+package github
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+	"github.com/ervinmplayon/tractatus/internal/output"
+	"github.com/ervinmplayon/tractatus/internal/snapshot"
+)
+
+// Writes inv as --format json output recording settings, and loads it back the way --baseline does
+func jsonOutputBaseline(t *testing.T, inv *inventory.Inventory, settings map[string]string) *snapshot.Snapshot {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "repos.json")
+	if err := output.NewFileJSONWriter(filename, settings).Write(inv); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := snapshot.NewStore(t.TempDir()).Resolve(filename)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	return snap
+}
+
+func TestJSONOutputBaseline(t *testing.T) {
+	inv := &inventory.Inventory{Resources: []*inventory.ResourceInfo{
+		{AppName: "api", GitHubRepo: "api", Source: "GitHub", PushedAt: "2026-01-01T00:00:00Z"},
+	}}
+	collected := Options{Recursive: true}
+
+	tests := []struct {
+		name       string
+		settings   map[string]string // recorded in the JSON output
+		run        Options
+		wantReused bool
+	}{
+		{"same settings", collected.Settings(), collected, true},
+		{"different settings", collected.Settings(), Options{}, false},
+		{"no settings recorded", nil, collected, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snap := jsonOutputBaseline(t, inv, tt.settings)
+
+			run := tt.run
+			run.Baseline = snap.Inventory
+			run.BaselineSettings = snap.Options
+			rows := (&DataSource{opts: run}).usableBaseline()
+
+			if reused := rows != nil; reused != tt.wantReused {
+				t.Fatalf("baseline usable = %v, want %v (recorded %v)", reused, tt.wantReused, snap.Options)
+			}
+			if tt.wantReused && (len(rows["api"]) != 1 || rows["api"][0].PushedAt != "2026-01-01T00:00:00Z") {
+				t.Errorf("baseline rows = %+v, want api as written", rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package github
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Most Helm charts and kustomizations read per repository, shallowest first
+const maxKubernetesFiles = 10
+
+// App names in Helm charts and kustomizations
+var (
+	chartNamePattern       = regexp.MustCompile(`(?m)^name:[ \t]*['"]?([\w.-]+)`)
+	kustomizePrefixPattern = regexp.MustCompile(`(?m)^namePrefix:[ \t]*['"]?([\w.-]+)`)
+	kustomizeLabelPattern  = regexp.MustCompile(`(?m)^[ \t]+(?:app|app\.kubernetes\.io/name):[ \t]*['"]?([\w.-]+)`)
+)
+
+// Files that name Kubernetes apps
+var kubernetesManifestFiles = map[string]bool{
+	"Chart.yaml":         true,
+	"kustomization.yaml": true,
+	"kustomization.yml":  true,
+}
+
+// Returns the Helm charts and kustomizations in files, shallowest first, capped at maxKubernetesFiles
+func kubernetesManifests(files []string) []string {
+	var manifests []string
+	for _, file := range files {
+		if kubernetesManifestFiles[path.Base(file)] {
+			manifests = append(manifests, file)
+		}
+	}
+
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return strings.Count(manifests[i], "/") < strings.Count(manifests[j], "/")
+	})
+	if len(manifests) > maxKubernetesFiles {
+		manifests = manifests[:maxKubernetesFiles]
+	}
+	return manifests
+}
+
+// Reads app names out of fetched Helm charts (name:) and kustomizations (namePrefix:, app labels), sorted
+func kubernetesApps(contents map[string]string) []string {
+	seen := make(map[string]bool)
+	var apps []string
+	add := func(name string) {
+		name = strings.TrimRight(name, "-.")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			apps = append(apps, name)
+		}
+	}
+
+	for file, content := range contents {
+		switch path.Base(file) {
+		case "Chart.yaml":
+			if match := chartNamePattern.FindStringSubmatch(content); match != nil {
+				add(match[1])
+			}
+		case "kustomization.yaml", "kustomization.yml":
+			if match := kustomizePrefixPattern.FindStringSubmatch(content); match != nil {
+				add(match[1])
+			}
+			for _, match := range kustomizeLabelPattern.FindAllStringSubmatch(content, -1) {
+				add(match[1])
+			}
+		}
+	}
+
+	sort.Strings(apps)
+	return apps
+}
@@ -0,0 +1,142 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Resolves CODEOWNERS handles to people through the GitHub API.
+// Owners repeat across most repos in an org, so each handle's answer is looked up once and shared between workers;
+// only lookups that failed are tried again.
+type ownerResolver struct {
+	client *Client
+
+	mu    sync.Mutex
+	cache map[string]*ownerLookup
+}
+
+// A single handle's lookup; mu keeps two workers from resolving the same handle at the same time
+type ownerLookup struct {
+	mu     sync.Mutex
+	done   bool // detail is a definitive answer; a failed lookup (rate limit, network) is retried by the next repo
+	detail inventory.OwnerDetail
+}
+
+func newOwnerResolver(client *Client) *ownerResolver {
+	return &ownerResolver{
+		client: client,
+		cache:  make(map[string]*ownerLookup),
+	}
+}
+
+// Resolves every owner in order
+func (r *ownerResolver) ResolveAll(ctx context.Context, owners []string) []inventory.OwnerDetail {
+	details := make([]inventory.OwnerDetail, 0, len(owners))
+	for _, owner := range owners {
+		details = append(details, r.Resolve(ctx, owner))
+	}
+	return details
+}
+
+// Resolves a single CODEOWNERS handle: "org/team", "user" or an email address
+func (r *ownerResolver) Resolve(ctx context.Context, handle string) inventory.OwnerDetail {
+	r.mu.Lock()
+	lookup, exists := r.cache[handle]
+	if !exists {
+		lookup = &ownerLookup{}
+		r.cache[handle] = lookup
+	}
+	r.mu.Unlock()
+
+	lookup.mu.Lock()
+	defer lookup.mu.Unlock()
+	if !lookup.done {
+		detail := r.lookup(ctx, handle)
+		if detail.Status == inventory.OwnerLookupFail {
+			return detail
+		}
+		lookup.detail, lookup.done = detail, true
+	}
+
+	// Copy the members so callers can't modify the shared cache entry
+	detail := lookup.detail
+	detail.Members = append([]string(nil), detail.Members...)
+	return detail
+}
+
+// Does the actual API calls for a handle
+func (r *ownerResolver) lookup(ctx context.Context, handle string) inventory.OwnerDetail {
+	switch {
+	case strings.Contains(handle, "/"):
+		return r.lookupTeam(ctx, handle)
+	case strings.Contains(handle, "@"):
+		// The API can't map an email to an account unless the user made it public, so don't guess
+		return inventory.OwnerDetail{Handle: handle, Kind: "email", Status: inventory.OwnerUnverified, Email: handle}
+	default:
+		return r.lookupUser(ctx, handle)
+	}
+}
+
+// Resolves "org/team-slug" to its members
+func (r *ownerResolver) lookupTeam(ctx context.Context, handle string) inventory.OwnerDetail {
+	detail := inventory.OwnerDetail{Handle: handle, Kind: "team"}
+	org, slug, _ := strings.Cut(handle, "/")
+
+	// GitHub only honours teams from the repo's own org, no need to ask
+	if !strings.EqualFold(org, r.client.Org()) {
+		detail.Status = inventory.OwnerNotInOrg
+		return detail
+	}
+
+	members, found, err := r.client.ListTeamMembers(ctx, org, slug)
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: failed to resolve team %s: %v\n", handle, err)
+		detail.Status = inventory.OwnerLookupFail
+	case !found:
+		detail.Status = inventory.OwnerNotFound
+	case len(members) == 0:
+		detail.Status = inventory.OwnerEmptyTeam
+	default:
+		detail.Status = inventory.OwnerOK
+		detail.Members = members
+	}
+
+	return detail
+}
+
+// Resolves a user login to a name/email and checks they're still in the org
+func (r *ownerResolver) lookupUser(ctx context.Context, login string) inventory.OwnerDetail {
+	detail := inventory.OwnerDetail{Handle: login, Kind: "user"}
+
+	user, err := r.client.GetUser(ctx, login)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to resolve user %s: %v\n", login, err)
+		detail.Status = inventory.OwnerLookupFail
+		return detail
+	}
+	if user == nil {
+		detail.Status = inventory.OwnerNotFound
+		return detail
+	}
+	detail.DisplayName = user.Name
+	detail.Email = user.Email
+
+	member, err := r.client.IsOrgMember(ctx, r.client.Org(), login)
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: failed to check org membership for %s: %v\n", login, err)
+		detail.Status = inventory.OwnerLookupFail
+	case !member:
+		detail.Status = inventory.OwnerNotInOrg
+	default:
+		detail.Status = inventory.OwnerOK
+	}
+
+	return detail
+}
@@ -0,0 +1,49 @@
+package github
+
+import (
+	"context"
+	"sync"
+)
+
+// Default number of repositories analyzed at once
+const DefaultWorkers = 8
+
+// Runs fn for every index in [0, n) on at most `workers` goroutines.
+// Callers write results into a slice by index, so output order stays deterministic no matter which worker finishes first.
+// Once ctx is cancelled no new work is handed out and ctx.Err() is returned after in-flight calls finish.
+func runPool(ctx context.Context, n, workers int, fn func(ctx context.Context, i int)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(ctx, i)
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return err
+}
@@ -0,0 +1,270 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxRateLimitRetries = 5                // attempts per request before the rate-limited response is handed back
+	secondaryBaseDelay  = 60 * time.Second // GitHub asks for at least a minute after a secondary limit without Retry-After
+	secondaryMaxDelay   = 15 * time.Minute
+	quotaReportEvery    = 100 // print remaining quota every N responses
+)
+
+// Wraps an http.RoundTripper so GitHub rate limits pause and retry the request instead of failing it.
+//
+// Primary limit (X-RateLimit-Remaining hits 0): every worker waits until X-RateLimit-Reset, then the call is retried.
+// Secondary/abuse limit: wait for Retry-After, or back off exponentially with jitter when GitHub doesn't say.
+// GraphQL: a 200 with a RATE_LIMITED error is treated the same way, from the same headers.
+// Waiting happens here, below go-github. go-github remembers an exhausted window from the headers and refuses
+// later calls with "not making remote request", so once the transport owns the wait it strips those headers.
+type rateLimitTransport struct {
+	base http.RoundTripper
+
+	mu          sync.Mutex
+	pausedUntil time.Time // shared so all workers stop together instead of each tripping the limit
+	responses   int
+}
+
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{base: base}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.waitForPause(req); err != nil {
+			return nil, err
+		}
+
+		attemptReq, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining, limit, reset := parseRateHeaders(resp)
+		t.reportQuota(remaining, limit, reset)
+
+		wait, limited := rateLimitWait(resp, remaining, reset, attempt)
+		if !limited {
+			// Successful call that used the last request in the window: hold everyone until reset
+			if remaining == 0 && !reset.IsZero() {
+				t.pause(time.Until(reset)+time.Second, "primary rate limit exhausted")
+				hideRateLimitState(resp)
+			}
+			return resp, nil
+		}
+
+		// Out of retries, or a body we can't resend: let go-github turn this one into a RateLimitError,
+		// and hold the next requests here rather than have go-github refuse them unsent
+		if attempt+1 >= maxRateLimitRetries || (req.Body != nil && req.GetBody == nil) {
+			t.pause(wait, fmt.Sprintf("rate limited on %s, giving up after %d attempts", req.URL.Path, attempt+1))
+			hideRateLimitState(resp)
+			return resp, nil
+		}
+
+		// Drain so the connection can be reused
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		t.pause(wait, fmt.Sprintf("rate limited on %s (attempt %d/%d)", req.URL.Path, attempt+1, maxRateLimitRetries))
+	}
+}
+
+// Blocks until any shared pause is over or the request's context is cancelled
+func (t *rateLimitTransport) waitForPause(req *http.Request) error {
+	t.mu.Lock()
+	wait := time.Until(t.pausedUntil)
+	t.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Extends the shared pause; the first worker to hit a limit announces it
+func (t *rateLimitTransport) pause(wait time.Duration, reason string) {
+	if wait <= 0 {
+		return
+	}
+
+	until := time.Now().Add(wait)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until.Before(t.pausedUntil) {
+		return
+	}
+	t.pausedUntil = until
+	fmt.Fprintf(os.Stderr, "GitHub %s, waiting %s until %s\n", reason, wait.Round(time.Second), until.Format("15:04:05"))
+}
+
+// Prints the remaining quota on stderr every quotaReportEvery responses
+func (t *rateLimitTransport) reportQuota(remaining, limit int, reset time.Time) {
+	if remaining < 0 {
+		return
+	}
+
+	t.mu.Lock()
+	t.responses++
+	report := t.responses%quotaReportEvery == 0
+	t.mu.Unlock()
+
+	if report {
+		fmt.Fprintf(os.Stderr, "GitHub API quota: %d/%d remaining, resets at %s\n", remaining, limit, reset.Format("15:04:05"))
+	}
+}
+
+// Removes the headers go-github uses to block requests until a limit resets; the shared pause already does that
+func hideRateLimitState(resp *http.Response) {
+	resp.Header.Del("X-RateLimit-Remaining")
+	resp.Header.Del("X-RateLimit-Reset")
+	resp.Header.Del("Retry-After")
+}
+
+// Decides whether a response is a rate-limit rejection and how long to wait before retrying
+func rateLimitWait(resp *http.Response, remaining int, reset time.Time, attempt int) (time.Duration, bool) {
+	graphql := resp.StatusCode == http.StatusOK && isGraphQLRequest(resp.Request)
+	if graphql {
+		// GraphQL reports its limit as a 200 with a RATE_LIMITED error instead of a 403
+		if !isGraphQLRateLimited(resp) {
+			return 0, false
+		}
+	} else if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	// Primary limit: the window is used up, wait for it to roll over
+	if remaining == 0 && !reset.IsZero() {
+		return time.Until(reset) + time.Second, true
+	}
+
+	// Secondary limit: GitHub usually sends Retry-After
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return withJitter(time.Duration(seconds) * time.Second), true
+		}
+	}
+
+	// No header, so we have to look at the message to tell a secondary limit from a plain permission 403
+	if !graphql && !isSecondaryRateLimit(resp) {
+		return 0, false
+	}
+
+	delay := secondaryBaseDelay << attempt
+	if delay > secondaryMaxDelay {
+		delay = secondaryMaxDelay
+	}
+	return withJitter(delay), true
+}
+
+// Peeks at the error body for GitHub's secondary/abuse rate-limit wording, leaving the body readable
+func isSecondaryRateLimit(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	msg := strings.ToLower(string(body))
+	return strings.Contains(msg, "secondary rate limit") || strings.Contains(msg, "abuse")
+}
+
+// Reports whether the request is a GraphQL query, the only 200s that can carry a rate limit
+func isGraphQLRequest(req *http.Request) bool {
+	return req != nil && req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/graphql")
+}
+
+// Peeks at a GraphQL answer for a RATE_LIMITED error, leaving the body readable
+func isGraphQLRateLimited(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	var answer struct {
+		Errors []struct {
+			Type string `json:"type"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &answer) != nil {
+		return false
+	}
+	for _, gqlErr := range answer.Errors {
+		if gqlErr.Type == graphqlRateLimited {
+			return true
+		}
+	}
+	return false
+}
+
+// Reads X-RateLimit-* headers. remaining is -1 when GitHub didn't send them.
+func parseRateHeaders(resp *http.Response) (remaining, limit int, reset time.Time) {
+	remaining = -1
+	if v := resp.Header.Get("X-RateLimit-Remaining"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			remaining = n
+		}
+	}
+	if v := resp.Header.Get("X-RateLimit-Limit"); v != "" {
+		limit, _ = strconv.Atoi(v)
+	}
+	if v := resp.Header.Get("X-RateLimit-Reset"); v != "" {
+		if epoch, err := strconv.ParseInt(v, 10, 64); err == nil && epoch > 0 {
+			reset = time.Unix(epoch, 0)
+		}
+	}
+	return remaining, limit, reset
+}
+
+// Adds up to 25% random jitter so paused workers don't all retry in the same instant
+func withJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/4+1))
+}
+
+// Returns a request that can be sent again, re-opening the body for retries
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("rewindRequest: failed to reset request body: %w", err)
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
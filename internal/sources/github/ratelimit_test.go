@@ -0,0 +1,39 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+func TestRateLimitTransportLetsGoGitHubRetryAfterReset(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		// Every answer uses up the window, which resets a moment later
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10))
+		w.Write([]byte(`{"name":"api"}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(&http.Client{Transport: newRateLimitTransport(nil)})
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	for call := 1; call <= 2; call++ {
+		if _, _, err := client.Repositories.Get(context.Background(), "org", "api"); err != nil {
+			t.Fatalf("call %d: %v", call, err)
+		}
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server got %d requests, want 2 (the second waits out the reset instead of being refused)", got)
+	}
+}
@@ -0,0 +1,383 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// What a rule detects
+const (
+	RuleCICD          = "cicd"           // Value is the CI/CD system; every match is reported, with the paths that matched
+	RulePlatform      = "platform"       // Value is a deployment platform; every match is reported
+	RuleTests         = "tests"          // Value is the label reported when the repo has tests
+	RuleTestFramework = "test_framework" // Value is a test framework, added to the ones read from manifests
+	RuleExclude       = "exclude"        // Value is the reason the repo is skipped ("EKS")
+	RuleDeploy        = "deploy"         // matched against file names; the directory holding a match is a deployable unit
+)
+
+// The exclude rule value Options.IncludeEKS turns into a Platform instead
+const EKSExclusion = "EKS"
+
+// Most files fetched per repository for content rules, shallowest first
+const maxContentFiles = 20
+
+// A single detection signal. Rules are loaded from a JSON rules file (see LoadRules) on top of DefaultRules.
+type Rule struct {
+	Name     string    `json:"name"`               // unique; a file rule with a default's name replaces it
+	Detects  string    `json:"detects"`            // one of the Rule* kinds
+	Value    string    `json:"value"`              // what is reported when the rule matches
+	Priority int       `json:"priority,omitempty"` // higher is checked first; equal priorities keep file order
+	Disabled bool      `json:"disabled,omitempty"` // drops the rule, mostly to switch off a default
+	Match    RuleMatch `json:"match"`
+
+	// For platform rules: "high", "medium" (default) or "low". Only the most confident platforms are reported,
+	// so a deploy step in CI outranks a Dockerfile.
+	Confidence string `json:"confidence,omitempty"`
+}
+
+// When a rule matches. A rule matches if any path matcher (exact, prefix, glob) matches a file
+// and, when set, the content matcher matches too.
+type RuleMatch struct {
+	Exact   []string      `json:"exact,omitempty"`  // a path or whole path segments at any depth: "Dockerfile" matches "svc/Dockerfile"
+	Prefix  []string      `json:"prefix,omitempty"` // the path starts with it: "deploy/" matches "deploy/prod.yml"
+	Glob    []string      `json:"glob,omitempty"`   // path.Match on the path, or on the file name when the pattern has no "/"
+	Content *ContentMatch `json:"content,omitempty"`
+}
+
+// Matches files by content; the files are fetched through the API, so keep the globs narrow
+type ContentMatch struct {
+	File  string   `json:"file,omitempty"`  // glob, same rules as RuleMatch.Glob
+	Files []string `json:"files,omitempty"` // more globs, for rules that read several kinds of file
+	Regex string   `json:"regex"`           // Go regexp syntax
+
+	regex *regexp.Regexp
+}
+
+// Reports whether a fetched file is one the content matcher reads
+func (c *ContentMatch) covers(file string) bool {
+	if c.File != "" && matchesGlob(file, c.File) {
+		return true
+	}
+	for _, pattern := range c.Files {
+		if matchesGlob(file, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// CI configuration files, which deploy rules read
+var ciConfigGlobs = []string{
+	".github/workflows/*.yml",
+	".github/workflows/*.yaml",
+	".circleci/config.yml",
+	"Jenkinsfile",
+	".gitlab-ci.yml",
+}
+
+// Builds a built-in content matcher over the CI configuration files
+func ciContent(expr string) *ContentMatch {
+	return &ContentMatch{Files: ciConfigGlobs, Regex: expr, regex: regexp.MustCompile(expr)}
+}
+
+// The rules file layout
+type rulesFile struct {
+	ReplaceDefaults bool    `json:"replace_defaults"` // start from an empty set instead of DefaultRules
+	Rules           []*Rule `json:"rules"`
+}
+
+// Returns the built-in rules: the CI/CD, test, platform, EKS and deploy conventions tractatus has always used
+func DefaultRules() []*Rule {
+	return []*Rule{
+		// CI/CD systems
+		{Name: "circleci", Detects: RuleCICD, Value: "CircleCI", Match: RuleMatch{Exact: []string{".circleci"}}},
+		{Name: "github-actions", Detects: RuleCICD, Value: "GitHub Actions", Match: RuleMatch{
+			Glob: []string{".github/workflows/*.yml", ".github/workflows/*.yaml"}, // workflow files, not just a .github dir
+		}},
+		{Name: "bitbucket-pipelines", Detects: RuleCICD, Value: "Bitbucket Pipelines", Match: RuleMatch{Exact: []string{"bitbucket-pipelines.yml"}}},
+		{Name: "gitlab-ci", Detects: RuleCICD, Value: "GitLab CI", Match: RuleMatch{Exact: []string{".gitlab-ci.yml"}}},
+		{Name: "jenkins", Detects: RuleCICD, Value: "Jenkins", Match: RuleMatch{Glob: []string{"Jenkinsfile*"}}},
+		{Name: "travis-ci", Detects: RuleCICD, Value: "Travis CI", Match: RuleMatch{Exact: []string{".travis.yml"}}},
+		{Name: "azure-pipelines", Detects: RuleCICD, Value: "Azure Pipelines", Match: RuleMatch{Exact: []string{"azure-pipelines.yml"}}},
+
+		// Tests: directories are the stronger signal, so they're reported first
+		{Name: "test-directories", Detects: RuleTests, Value: "detected test directory", Priority: 1, Match: RuleMatch{
+			Exact: []string{"test", "tests", "__tests__", "spec", "test_suite", "testing"},
+		}},
+		{Name: "test-files", Detects: RuleTests, Value: "detected test files", Match: RuleMatch{
+			Glob: []string{"*_test.go", "*.spec.js", "*.test.js", "*.spec.ts", "*.test.ts", "*Test.java", "test_*"},
+		}},
+
+		// EKS applications are inventoried elsewhere (kept with --include-eks, see Options.IncludeEKS)
+		{Name: "eks", Detects: RuleExclude, Value: EKSExclusion, Match: RuleMatch{
+			Exact: []string{"k8s", "kubernetes", ".kube", "helm", "Chart.yaml", "kustomization.yaml", "kustomization.yml"},
+		}},
+
+		// Deployment platforms: deploy steps in CI pipelines
+		{Name: "ecs-deploy", Detects: RulePlatform, Value: "ECS", Confidence: inventory.ConfidenceHigh, Match: RuleMatch{
+			Content: ciContent(`aws-actions/amazon-ecs-deploy-task-definition|aws ecs update-service|aws ecs deploy|circleci/aws-ecs@|\becs-deploy\b`),
+		}},
+		{Name: "serverless-deploy", Detects: RulePlatform, Value: "Lambda", Confidence: inventory.ConfidenceHigh, Match: RuleMatch{
+			Content: ciContent(`\b(serverless|sls) deploy\b|serverless/github-action|circleci/serverless-framework@`),
+		}},
+		{Name: "sam-deploy", Detects: RulePlatform, Value: "Lambda", Confidence: inventory.ConfidenceHigh, Match: RuleMatch{
+			Content: ciContent(`\bsam deploy\b|aws lambda update-function-code`),
+		}},
+		{Name: "eb-deploy", Detects: RulePlatform, Value: "Elastic Beanstalk", Confidence: inventory.ConfidenceHigh, Match: RuleMatch{
+			Content: ciContent(`\beb deploy\b|einaregilsson/beanstalk-deploy|circleci/aws-elastic-beanstalk@`),
+		}},
+		{Name: "kubernetes-deploy", Detects: RulePlatform, Value: inventory.PlatformEKS, Confidence: inventory.ConfidenceHigh, Match: RuleMatch{
+			Content: ciContent(`\bkubectl (apply|set image|rollout)\b|\bhelm (upgrade|install)\b|azure/k8s-deploy`),
+		}},
+
+		// Deployment platforms: platform config in the repo
+		{Name: "ecs", Detects: RulePlatform, Value: "ECS", Confidence: inventory.ConfidenceMedium, Match: RuleMatch{
+			Exact: []string{"ecs-task-definition.json", "ecs-service.json"},
+		}},
+		{Name: "lambda", Detects: RulePlatform, Value: "Lambda", Confidence: inventory.ConfidenceMedium, Match: RuleMatch{
+			Exact: []string{"serverless.yml", "serverless.yaml", "template.yaml", "template.yml"}, // template.y*ml is SAM
+		}},
+		{Name: "elastic-beanstalk", Detects: RulePlatform, Value: "Elastic Beanstalk", Confidence: inventory.ConfidenceMedium, Match: RuleMatch{
+			Exact: []string{".ebextensions", "Procfile", ".elasticbeanstalk"},
+		}},
+
+		// Deployment platforms: generic hints, reported only when nothing better matched
+		{Name: "dockerfile", Detects: RulePlatform, Value: "ECS", Confidence: inventory.ConfidenceLow, Match: RuleMatch{
+			Exact: []string{"Dockerfile"},
+		}},
+		{Name: "lambda-dirs", Detects: RulePlatform, Value: "Lambda", Confidence: inventory.ConfidenceLow, Match: RuleMatch{
+			Exact: []string{"lambda", "functions"},
+		}},
+
+		// Files whose directory is a deployable unit: deployment config, or its own package manifest
+		{Name: "package-units", Detects: RuleDeploy, Match: RuleMatch{
+			Exact: []string{"package.json", "go.mod", "pyproject.toml", "setup.py", "pom.xml", "build.gradle", "build.gradle.kts", "Gemfile"},
+		}},
+		{Name: "deploy-units", Detects: RuleDeploy, Match: RuleMatch{
+			Exact: []string{
+				"Dockerfile",
+				"ecs-task-definition.json",
+				"ecs-service.json",
+				"serverless.yml",
+				"serverless.yaml",
+				"template.yaml",
+				"template.yml",
+				"Procfile",
+				".ebextensions",
+				".elasticbeanstalk",
+			},
+		}},
+	}
+}
+
+// Reads a JSON rules file and merges it with DefaultRules: a rule named like a default replaces it,
+// "disabled": true removes it, new names are added after the defaults.
+func LoadRules(filename string) ([]*Rule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("loadRules: failed to read %s: %w", filename, err)
+	}
+
+	var file rulesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("loadRules: failed to parse %s: %w", filename, err)
+	}
+
+	var rules []*Rule
+	if !file.ReplaceDefaults {
+		rules = DefaultRules()
+	}
+
+	seen := make(map[string]bool)
+	for _, rule := range file.Rules {
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("loadRules: rule %q is defined twice", rule.Name)
+		}
+		seen[rule.Name] = true
+
+		if !rule.Disabled {
+			if err := rule.validate(); err != nil {
+				return nil, fmt.Errorf("loadRules: %w", err)
+			}
+		}
+		rules = mergeRule(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Replaces the rule with the same name, or appends it; disabled rules are dropped
+func mergeRule(rules []*Rule, rule *Rule) []*Rule {
+	for i, existing := range rules {
+		if existing.Name != rule.Name {
+			continue
+		}
+		if rule.Disabled {
+			return append(rules[:i], rules[i+1:]...)
+		}
+		rules[i] = rule
+		return rules
+	}
+
+	if rule.Disabled {
+		return rules
+	}
+	return append(rules, rule)
+}
+
+// Checks a rule is usable and compiles its content regex
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule without a name")
+	}
+
+	switch r.Detects {
+	case RuleCICD, RulePlatform, RuleTests, RuleTestFramework, RuleExclude:
+		if r.Value == "" {
+			return fmt.Errorf("rule %q: value is required for %q rules", r.Name, r.Detects)
+		}
+	case RuleDeploy:
+	default:
+		return fmt.Errorf("rule %q: unknown detects %q", r.Name, r.Detects)
+	}
+
+	switch r.Confidence {
+	case "", inventory.ConfidenceHigh, inventory.ConfidenceMedium, inventory.ConfidenceLow:
+	default:
+		return fmt.Errorf("rule %q: confidence must be high, medium or low", r.Name)
+	}
+
+	m := r.Match
+	if len(m.Exact) == 0 && len(m.Prefix) == 0 && len(m.Glob) == 0 && m.Content == nil {
+		return fmt.Errorf("rule %q: match needs at least one of exact, prefix, glob or content", r.Name)
+	}
+	for _, pattern := range m.Glob {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("rule %q: bad glob %q: %w", r.Name, pattern, err)
+		}
+	}
+
+	if m.Content != nil {
+		if r.Detects == RuleDeploy {
+			return fmt.Errorf("rule %q: deploy rules can't match on content", r.Name)
+		}
+		globs := m.Content.Files
+		if m.Content.File != "" {
+			globs = append([]string{m.Content.File}, globs...)
+		}
+		if len(globs) == 0 || m.Content.Regex == "" {
+			return fmt.Errorf("rule %q: content needs a file and a regex", r.Name)
+		}
+		for _, pattern := range globs {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %q: bad content file glob %q: %w", r.Name, pattern, err)
+			}
+		}
+		regex, err := regexp.Compile(m.Content.Regex)
+		if err != nil {
+			return fmt.Errorf("rule %q: bad content regex: %w", r.Name, err)
+		}
+		m.Content.regex = regex
+	}
+
+	return nil
+}
+
+// Reports whether the rule matches a repository's files and fetched contents (path -> content)
+func (r *Rule) matches(files []string, contents map[string]string) bool {
+	return len(r.evidence(files, contents)) > 0
+}
+
+// Returns every path that makes the rule match, content files first; empty if it doesn't match
+func (r *Rule) evidence(files []string, contents map[string]string) []string {
+	var pathMatches []string
+	if r.hasPathMatch() {
+		for _, file := range files {
+			if r.matchesFile(file) {
+				pathMatches = append(pathMatches, file)
+			}
+		}
+		if len(pathMatches) == 0 {
+			return nil
+		}
+	}
+
+	content := r.Match.Content
+	if content == nil {
+		return pathMatches
+	}
+
+	// Sorted so the evidence doesn't depend on map order
+	paths := make([]string, 0, len(contents))
+	for file := range contents {
+		paths = append(paths, file)
+	}
+	sort.Strings(paths)
+
+	var contentMatches []string
+	for _, file := range paths {
+		if content.covers(file) && content.regex != nil && content.regex.MatchString(contents[file]) {
+			contentMatches = append(contentMatches, file)
+		}
+	}
+	if len(contentMatches) == 0 {
+		return nil
+	}
+	return append(contentMatches, pathMatches...)
+}
+
+// Reports whether the rule has any exact, prefix or glob matcher
+func (r *Rule) hasPathMatch() bool {
+	return len(r.Match.Exact) > 0 || len(r.Match.Prefix) > 0 || len(r.Match.Glob) > 0
+}
+
+// Reports whether a single path satisfies the rule's exact, prefix or glob matchers
+func (r *Rule) matchesFile(file string) bool {
+	for _, exact := range r.Match.Exact {
+		if matchesPath(file, exact) {
+			return true
+		}
+	}
+	for _, prefix := range r.Match.Prefix {
+		if strings.HasPrefix(file, prefix) {
+			return true
+		}
+	}
+	for _, pattern := range r.Match.Glob {
+		if matchesGlob(file, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches a glob against the whole path, or only the file name when the pattern has no "/"
+func matchesGlob(file, pattern string) bool {
+	target := file
+	if !strings.Contains(pattern, "/") {
+		target = path.Base(file)
+	}
+	ok, _ := path.Match(pattern, target)
+	return ok
+}
+
+// Orders rules by priority, highest first, keeping the given order among equals
+func sortRules(rules []*Rule) []*Rule {
+	sorted := make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.Disabled {
+			sorted = append(sorted, rule)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	return sorted
+}
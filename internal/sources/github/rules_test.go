@@ -0,0 +1,162 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Writes content to a rules file in a temp dir and returns its path
+func writeRulesFile(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// Returns the rule names in order
+func ruleNames(rules []*Rule) []string {
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+	return names
+}
+
+func indexOfRule(rules []*Rule, name string) int {
+	for i, rule := range rules {
+		if rule.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestMergeRule(t *testing.T) {
+	base := func() []*Rule {
+		return []*Rule{{Name: "a", Value: "A"}, {Name: "b", Value: "B"}, {Name: "c", Value: "C"}}
+	}
+
+	tests := []struct {
+		name      string
+		rule      *Rule
+		wantNames string
+		check     func(t *testing.T, rules []*Rule)
+	}{
+		{
+			name:      "same name replaces in place",
+			rule:      &Rule{Name: "b", Value: "B2"},
+			wantNames: "a,b,c",
+			check: func(t *testing.T, rules []*Rule) {
+				if rules[1].Value != "B2" {
+					t.Errorf("b = %q, want the replacement", rules[1].Value)
+				}
+			},
+		},
+		{
+			name:      "new name is appended",
+			rule:      &Rule{Name: "d", Value: "D"},
+			wantNames: "a,b,c,d",
+		},
+		{
+			name:      "disabled removes",
+			rule:      &Rule{Name: "a", Disabled: true},
+			wantNames: "b,c",
+		},
+		{
+			name:      "disabling an unknown rule changes nothing",
+			rule:      &Rule{Name: "z", Disabled: true},
+			wantNames: "a,b,c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := mergeRule(base(), tt.rule)
+			if got := strings.Join(ruleNames(rules), ","); got != tt.wantNames {
+				t.Errorf("rules = %s, want %s", got, tt.wantNames)
+			}
+			if tt.check != nil {
+				tt.check(t, rules)
+			}
+		})
+	}
+}
+
+func TestLoadRulesMergesWithDefaults(t *testing.T) {
+	defaults := DefaultRules()
+	replaced := defaults[0].Name
+	disabled := defaults[1].Name
+
+	rules, err := LoadRules(writeRulesFile(t, `{"rules": [
+		{"name": "`+replaced+`", "detects": "cicd", "value": "Custom CI", "match": {"exact": ["ci.yml"]}},
+		{"name": "`+disabled+`", "disabled": true},
+		{"name": "argocd", "detects": "cicd", "value": "Argo CD", "match": {"prefix": ["argocd/"]}}
+	]}`))
+	if err != nil {
+		t.Fatalf("LoadRules: %v", err)
+	}
+
+	if len(rules) != len(defaults) {
+		t.Errorf("got %d rules, want %d (one replaced, one removed, one added)", len(rules), len(defaults))
+	}
+	if i := indexOfRule(rules, replaced); i != 0 {
+		t.Errorf("%s at %d, want it kept in the default's place", replaced, i)
+	} else if rules[0].Value != "Custom CI" {
+		t.Errorf("%s value = %q, want the file's", replaced, rules[0].Value)
+	}
+	if indexOfRule(rules, disabled) >= 0 {
+		t.Errorf("%s still present after being disabled", disabled)
+	}
+	if i := indexOfRule(rules, "argocd"); i != len(rules)-1 {
+		t.Errorf("argocd at %d, want it after the defaults", i)
+	}
+}
+
+func TestLoadRulesReplaceDefaults(t *testing.T) {
+	rules, err := LoadRules(writeRulesFile(t, `{"replace_defaults": true, "rules": [
+		{"name": "only", "detects": "tests", "value": "has specs", "match": {"glob": ["*_spec.rb"]}}
+	]}`))
+	if err != nil {
+		t.Fatalf("LoadRules: %v", err)
+	}
+	if got := strings.Join(ruleNames(rules), ","); got != "only" {
+		t.Errorf("rules = %s, want only the file's", got)
+	}
+}
+
+func TestLoadRulesExample(t *testing.T) {
+	if _, err := LoadRules(filepath.Join("..", "..", "..", "rules.example.json")); err != nil {
+		t.Errorf("rules.example.json: %v", err)
+	}
+}
+
+func TestLoadRulesRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr string
+	}{
+		{"duplicate name", `{"name": "x", "detects": "cicd", "value": "X", "match": {"exact": ["x"]}},
+			{"name": "x", "detects": "cicd", "value": "Y", "match": {"exact": ["y"]}}`, "defined twice"},
+		{"missing name", `{"detects": "cicd", "value": "X", "match": {"exact": ["x"]}}`, "without a name"},
+		{"unknown kind", `{"name": "x", "detects": "magic", "value": "X", "match": {"exact": ["x"]}}`, "unknown detects"},
+		{"missing value", `{"name": "x", "detects": "platform", "match": {"exact": ["x"]}}`, "value is required"},
+		{"no matcher", `{"name": "x", "detects": "cicd", "value": "X", "match": {}}`, "at least one"},
+		{"bad glob", `{"name": "x", "detects": "cicd", "value": "X", "match": {"glob": ["[x"]}}`, "bad glob"},
+		{"bad confidence", `{"name": "x", "detects": "platform", "value": "X", "confidence": "sure", "match": {"exact": ["x"]}}`, "confidence"},
+		{"content without regex", `{"name": "x", "detects": "platform", "value": "X", "match": {"content": {"file": "x.yml"}}}`, "file and a regex"},
+		{"bad content regex", `{"name": "x", "detects": "platform", "value": "X", "match": {"content": {"file": "x.yml", "regex": "("}}}`, "bad content regex"},
+		{"deploy on content", `{"name": "x", "detects": "deploy", "match": {"content": {"file": "x.yml", "regex": "x"}}}`, "can't match on content"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadRules(writeRulesFile(t, `{"rules": [`+tt.rules+`]}`))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadRules error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -4,28 +4,63 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/ervinmplayon/tractatus/internal/cache"
 	"github.com/ervinmplayon/tractatus/internal/inventory"
 	"github.com/google/go-github/v57/github"
 )
 
 // A DataSource needs the client to hook into platform, the detector for file detection
 type DataSource struct {
-	client          *Client
-	detector        *Detector
-	excludeArchived bool
+	client   *Client
+	detector *Detector
+	owners   *ownerResolver
+	opts     Options
+	skipped  []inventory.Skipped // repos the exclude rules dropped in the last Collect
 }
 
-func NewDataSource(token, org string, excludeArchived bool) (*DataSource, error) {
+// Tunes how the GitHub source collects
+type Options struct {
+	ExcludeArchived    bool
+	Workers            int                  // repositories analyzed concurrently, DefaultWorkers if unset
+	Recursive          bool                 // detect on nested paths, not just root entries
+	MaxDepth           int                  // path depth kept when Recursive, DefaultMaxDepth if unset
+	ResolveOwners      bool                 // look up CODEOWNERS users and teams through the API
+	ValidateCodeOwners bool                 // ask GitHub for CODEOWNERS syntax and owner errors
+	Rules              []*Rule              // detection rules, DefaultRules if nil
+	DeployUnits        bool                 // also emit one resource per deployable subdirectory (monorepos); needs Recursive
+	IncludeEKS         bool                 // keep repos the "EKS" exclude rules match, as EKS/Kubernetes, instead of skipping them
+	Cache              *cache.Cache         // on-disk response cache, nil to always fetch
+	Baseline           *inventory.Inventory // earlier run; repos not pushed since keep its results instead of being re-analyzed
+	BaselineSettings   map[string]string    // Settings() the baseline was collected with; the baseline is ignored if they differ
+	Backend            string               // BackendREST (default) or BackendGraphQL
+	App                *AppAuth             // authenticate as a GitHub App instead of with the token
+	BaseURL            string               // GitHub Enterprise Server URL, e.g. https://github.example.com; empty for github.com
+	UploadURL          string               // GHES upload URL, BaseURL if empty
+}
+
+func NewDataSource(token, org string, opts Options) (*DataSource, error) {
+	if opts.Workers < 1 {
+		opts.Workers = DefaultWorkers
+	}
+
 	ctx := context.Background()
-	client, err := NewClient(ctx, token, org)
+	client, err := NewClient(ctx, token, org, opts)
 	if err != nil {
 		return nil, fmt.Errorf("newDataSource error: %w", err)
 	}
+	detector := NewDetector()
+	if opts.Rules != nil {
+		detector = NewDetectorWithRules(opts.Rules)
+	}
+
 	return &DataSource{
-		client:          client,
-		detector:        NewDetector(),
-		excludeArchived: excludeArchived,
+		client:   client,
+		detector: detector,
+		owners:   newOwnerResolver(client),
+		opts:     opts,
 	}, nil
 }
 
@@ -35,29 +70,88 @@ func (ds *DataSource) Name() string {
 
 // Fetches all repositories and analyzes them
 func (ds *DataSource) Collect(ctx context.Context) ([]*inventory.ResourceInfo, error) {
-	repos, err := ds.client.ListRepositories(ctx, ds.excludeArchived)
+	// Incremental run: repos not pushed since the baseline skip the tree, commit and analysis calls
+	baseline := ds.usableBaseline()
+	var unchanged func(*Repository) bool
+	if baseline != nil {
+		unchanged = func(repo *Repository) bool {
+			return isUnchanged(baseline[repo.Name], repo)
+		}
+	}
+
+	repos, err := ds.client.ListRepositories(ctx, ds.opts.ExcludeArchived, unchanged)
 	if err != nil {
 		return nil, fmt.Errorf("collect failed to list repositories: %w", err)
 	}
 
-	var resources []*inventory.ResourceInfo
+	// Analyze each repository; results are slotted by index to keep the org's listing order
+	analyzed := make([][]*inventory.ResourceInfo, len(repos))
+	skipReasons := make([]string, len(repos))
+	err = runPool(ctx, len(repos), ds.opts.Workers, func(ctx context.Context, i int) {
+		if repos[i].Unchanged {
+			analyzed[i] = ds.carryForward(ctx, baseline[repos[i].Name], repos[i])
+			return
+		}
+		// Skip excluded (EKS) repositories before spending API calls on them
+		if reason := ds.exclusion(repos[i].Files, nil); reason != "" {
+			skipReasons[i] = reason
+			return
+		}
+		analyzed[i], skipReasons[i] = ds.analyzeRepository(ctx, repos[i])
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect failed to analyze repositories: %w", err)
+	}
 
-	// Analyze each repository
-	for _, repo := range repos {
-		// Skip EKS repositories
-		if ds.detector.IsEKS(repo.Files) {
-			continue
+	ds.skipped = nil
+	var resources []*inventory.ResourceInfo
+	for i, infos := range analyzed {
+		resources = append(resources, infos...)
+		if skipReasons[i] != "" {
+			ds.skipped = append(ds.skipped, inventory.Skipped{Name: repos[i].Name, Reason: skipReasons[i]})
+		}
+		// Still listed, but detection only saw part of the tree
+		if repos[i].PartialTree && skipReasons[i] == "" {
+			ds.skipped = append(ds.skipped, inventory.Skipped{Name: repos[i].Name, Reason: skipPartialTree})
 		}
+	}
 
-		info := ds.analyzeRepository(ctx, repo)
-		resources = append(resources, info)
+	if baseline != nil {
+		var reused int
+		for _, repo := range repos {
+			if repo.Unchanged {
+				reused++
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Incremental: %d repos refreshed, %d reused from the baseline\n", len(repos)-reused, reused)
 	}
 
 	return resources, nil
 }
 
-// Analyze a single repository
-func (ds *DataSource) analyzeRepository(ctx context.Context, repo *Repository) *inventory.ResourceInfo {
+// Returns the repos the exclude rules dropped in the last Collect, and those only partially scanned
+func (ds *DataSource) Skipped() []inventory.Skipped {
+	return ds.skipped
+}
+
+// Returns why the repo is excluded, or "" to keep it. EKS repos are kept with IncludeEKS
+func (ds *DataSource) exclusion(files []string, contents map[string]string) string {
+	excluded, reason := ds.detector.IsExcluded(files, contents)
+	if !excluded || (reason == EKSExclusion && ds.opts.IncludeEKS) {
+		return ""
+	}
+	return reason
+}
+
+// Analyze a single repository: its own row, followed by its deployable units with DeployUnits.
+// A repo a content rule excludes returns no resources and the exclusion reason.
+func (ds *DataSource) analyzeRepository(ctx context.Context, repo *Repository) ([]*inventory.ResourceInfo, string) {
+	// Manifests name test frameworks; content rules need their files too
+	contents := ds.getFileContents(ctx, repo)
+	if reason := ds.exclusion(repo.Files, contents); reason != "" {
+		return nil, reason
+	}
+
 	info := &inventory.ResourceInfo{
 		AppName:        repo.Name,
 		GitHubRepo:     repo.Name,
@@ -65,38 +159,59 @@ func (ds *DataSource) analyzeRepository(ctx context.Context, repo *Repository) *
 		IsArchived:     repo.IsArchived,
 		LastCommitter:  repo.LastCommitter,
 		LastCommitDate: repo.LastCommitDate,
+		PushedAt:       repo.PushedAt,
 	}
 
-	// Detect CI/CD
-	hasCICD, cicdPlatform := ds.detector.DetectCICD(repo.Files)
-	info.HasCICD = hasCICD
-	info.CICDPlatform = cicdPlatform
+	// Detect CI/CD; repos migrating between systems have several
+	for _, system := range ds.detector.DetectCICD(repo.Files, contents) {
+		if info.CICDEvidence == nil {
+			info.CICDEvidence = make(map[string][]string)
+		}
+		info.CICDPlatforms = append(info.CICDPlatforms, system.Name)
+		info.CICDEvidence[system.Name] = system.Evidence
+	}
+	info.HasCICD = len(info.CICDPlatforms) > 0
+	info.CICDPlatform = strings.Join(info.CICDPlatforms, ", ")
 
 	// Detect tests
-	hasTests, testFramework := ds.detector.DetectTests(repo.Files)
-	info.HasTests = hasTests
-	info.TestFramework = testFramework
+	ds.detectTests(info, repo.Files, contents)
+
+	// Detect platform, preferring what CI actually deploys to over files that merely hint at it
+	deployment := ds.detector.DetectDeployment(repo.Files, contents)
+	info.Platform = strings.Join(deployment.Platforms, ", ")
+	info.PlatformConfidence = deployment.Confidence
+	info.DeployEnvironments = deployment.Environments
+	info.DeployEvidence = deployment.Evidence
 
-	// Detect platform
-	info.Platform = ds.detector.DetectPlatform(repo.Files)
+	// Kubernetes apps (only reached with IncludeEKS): label them and name the apps their charts deploy
+	if ds.opts.IncludeEKS {
+		if evidence := ds.detector.KubernetesEvidence(repo.Files, contents); len(evidence) > 0 {
+			info.KubernetesApps = kubernetesApps(contents)
+			if !containsPath(deployment.Platforms, inventory.PlatformEKS) {
+				info.Platform = inventory.PlatformEKS
+				info.PlatformConfidence = inventory.ConfidenceMedium
+				info.DeployEvidence = []string{"eks in " + evidence[0]}
+			}
+		}
+	}
 
-	// Detect CODEOWNERS
+	// Detect CODEOWNERS. A root-only listing can't see .github/CODEOWNERS or docs/CODEOWNERS,
+	// so look whenever those directories exist.
 	info.HasCodeOwners = ds.detector.DetectCodeOwners(repo.Files)
+	mayHaveCodeOwners := info.HasCodeOwners ||
+		(!ds.opts.Recursive && (containsPath(repo.Dirs, ".github") || containsPath(repo.Dirs, "docs")))
 
 	// If CODEOWNERS exists, fetch and parse it
-	if info.HasCodeOwners {
+	if mayHaveCodeOwners {
 		codeownersContent, err := ds.getCodeOwnersContent(ctx, repo.Name)
+		info.HasCodeOwners = err == nil
 		if err == nil {
-			info.CodeOwners = ds.detector.ParseCodeOwners(codeownersContent)
-
-			// Set Owner and Team from CODEOWNERS
-			if len(info.CodeOwners) > 0 {
-				info.Owner = info.CodeOwners[0]
-				info.Team = info.CodeOwners[0]
-			}
+			ds.applyCodeOwners(info, repo, ParseCodeOwnersFile(codeownersContent))
 		}
 	}
 
+	ds.checkOwners(ctx, info, repo)
+
 	// If no owner found, set to Unknown
 	if info.Owner == "" {
 		info.Owner = "Unknown"
@@ -105,15 +220,127 @@ func (ds *DataSource) analyzeRepository(ctx context.Context, repo *Repository) *
 		info.Team = "Unknown"
 	}
 
-	return info
+	resources := []*inventory.ResourceInfo{info}
+	if ds.opts.DeployUnits {
+		resources = append(resources, ds.unitResources(repo, info, contents)...)
+	}
+	return resources, ""
+}
+
+// Validates CODEOWNERS and resolves its owners, as configured
+// Fills in HasTests and the test frameworks.
+// Frameworks come from the manifests, falling back to the generic detection label, and are only kept when
+// DetectTests finds tests: a declared framework alone doesn't mean there are any, and every writer shows the same row.
+func (ds *DataSource) detectTests(info *inventory.ResourceInfo, files []string, contents map[string]string) {
+	hasTests, testFramework := ds.detector.DetectTests(files, contents)
+	info.HasTests = hasTests
+	info.TestFramework = ""
+	info.TestFrameworks = nil
+	if !hasTests {
+		return
+	}
+
+	info.TestFramework = testFramework
+	info.TestFrameworks = ds.detector.DetectTestFrameworks(contents, files)
+	if len(info.TestFrameworks) > 0 {
+		info.TestFramework = strings.Join(info.TestFrameworks, ", ")
+	}
+}
+
+func (ds *DataSource) checkOwners(ctx context.Context, info *inventory.ResourceInfo, repo *Repository) {
+	// A CODEOWNERS GitHub can't parse is silently ignored, so ask it what's wrong
+	if info.HasCodeOwners && ds.opts.ValidateCodeOwners {
+		problems, err := ds.client.GetCodeOwnersErrors(ctx, repo.Name, repo.DefaultBranch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to validate CODEOWNERS for %s: %v\n", repo.Name, err)
+		}
+		info.CodeOwnersChecked = err == nil
+		for _, problem := range problems {
+			info.CodeOwnersErrors = append(info.CodeOwnersErrors, inventory.CodeOwnersError{
+				Line:       problem.Line,
+				Column:     problem.Column,
+				Kind:       problem.Kind,
+				Source:     problem.Source,
+				Message:    problem.Message,
+				Suggestion: problem.GetSuggestion(),
+				Path:       problem.Path,
+			})
+		}
+	}
+
+	// Check the owners are real, current people
+	if ds.opts.ResolveOwners && len(info.CodeOwners) > 0 {
+		info.OwnerDetails = ds.owners.ResolveAll(ctx, info.CodeOwners)
+	}
+}
+
+// Resolves ownership from CODEOWNERS: the catch-all owners, owners per deployable subdirectory, and unowned paths
+func (ds *DataSource) applyCodeOwners(info *inventory.ResourceInfo, repo *Repository, codeowners *CodeOwnersFile) {
+	info.CodeOwners = codeowners.AllOwners()
+	info.RootOwners = codeowners.RootOwners()
+
+	deployable := ds.detector.DeployableDirs(repo.Files)
+	if len(deployable) > 0 {
+		info.PathOwners = make(map[string][]string, len(deployable))
+		for _, dir := range deployable {
+			info.PathOwners[dir] = codeowners.Owners(dir, true)
+		}
+	}
+
+	dirs := make(map[string]bool, len(repo.Dirs))
+	for _, dir := range repo.Dirs {
+		dirs[dir] = true
+	}
+	info.UnownedPaths = codeowners.UnownedPaths(repo.Files, dirs)
+
+	// Set Owner and Team from CODEOWNERS, preferring whoever owns the whole repo
+	owners := info.RootOwners
+	if len(owners) == 0 {
+		owners = info.CodeOwners
+	}
+	if len(owners) > 0 {
+		info.Owner = owners[0]
+		info.Team = owners[0]
+	}
+}
+
+// Reports whether paths contains p
+func containsPath(paths []string, p string) bool {
+	for _, candidate := range paths {
+		if candidate == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetches the manifests that can name a test framework and the files content rules read; unreadable ones are skipped
+func (ds *DataSource) getFileContents(ctx context.Context, repo *Repository) map[string]string {
+	wanted := append(ds.detector.FindManifests(repo.Files), ds.detector.ContentFiles(repo.Files)...)
+	if ds.opts.IncludeEKS {
+		wanted = append(wanted, kubernetesManifests(repo.Files)...)
+	}
+
+	contents := make(map[string]string)
+	for _, file := range wanted {
+		if _, fetched := contents[file]; fetched {
+			continue
+		}
+		content, err := ds.client.GetFileContent(ctx, repo.Name, file)
+		if err != nil {
+			continue
+		}
+		contents[file] = content
+	}
+	return contents
 }
 
 // Fetches the CODEOWNERS file content
 func (ds *DataSource) getCodeOwnersContent(ctx context.Context, repoName string) (string, error) {
-	// Try common CODEOWNERS locations
+	// Try CODEOWNERS locations in GitHub's order of precedence; the first one found is the one GitHub uses
 	codeownersLocations := []string{
-		"CODEOWNERS",
 		".github/CODEOWNERS",
+		"CODEOWNERS",
 		"docs/CODEOWNERS",
 	}
 
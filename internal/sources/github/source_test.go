@@ -0,0 +1,35 @@
+package github
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+func TestDetectTestsDropsFrameworksWithoutTests(t *testing.T) {
+	ds := &DataSource{detector: NewDetector()}
+	contents := map[string]string{"package.json": `{"devDependencies": {"jest": "^29.0.0"}}`}
+
+	tests := []struct {
+		name           string
+		files          []string
+		wantTests      bool
+		wantFrameworks []string
+	}{
+		{"declared without tests", []string{"package.json", "src/index.js"}, false, nil},
+		{"declared with tests", []string{"package.json", "src/index.test.js"}, true, []string{"jest"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &inventory.ResourceInfo{TestFramework: "stale", TestFrameworks: []string{"stale"}}
+			ds.detectTests(info, tt.files, contents)
+			if info.HasTests != tt.wantTests || !slices.Equal(info.TestFrameworks, tt.wantFrameworks) {
+				t.Errorf("HasTests = %v, TestFrameworks = %v, want %v, %v", info.HasTests, info.TestFrameworks, tt.wantTests, tt.wantFrameworks)
+			}
+			if !tt.wantTests && info.TestFramework != "" {
+				t.Errorf("TestFramework = %q, want empty without tests", info.TestFramework)
+			}
+		})
+	}
+}
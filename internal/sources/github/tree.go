@@ -0,0 +1,156 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// Default path depth for recursive scans: services/api/.github/workflows/deploy.yml is depth 5
+const DefaultMaxDepth = 5
+
+// Most GetTree calls one truncated repo may spend on walkTree; a wide monorepo would otherwise drain the rate limit
+const maxTreeWalkCalls = 200
+
+// Skipped reason for repos whose walk hit maxTreeWalkCalls; they're still listed, detected on what was read
+const skipPartialTree = "partially scanned"
+
+// Dependency and build output directories; their contents would swamp detection (every node_modules has a "test" dir)
+var ignoredTreeDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	".git":         true,
+	"third_party":  true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	".venv":        true,
+	"venv":         true,
+}
+
+// Directories a root-only listing still expands, in order, because CI config lives in them.
+// Parents come first so their subdirectories' SHAs are known. Each one the repo has costs a GetTree call.
+var expandedDirs = []string{".github", ".github/workflows", ".circleci"}
+
+// Lists the contents of expandedDirs on top of a root-only listing; directories the repo doesn't have are skipped
+func (c *Client) expandCIDirs(ctx context.Context, repoName string, root []*github.TreeEntry) ([]string, []string) {
+	shas := make(map[string]string) // directory path -> tree SHA
+	for _, entry := range root {
+		if entry.GetType() == "tree" {
+			shas[entry.GetPath()] = entry.GetSHA()
+		}
+	}
+
+	var files, dirs []string
+	for _, dir := range expandedDirs {
+		sha, ok := shas[dir]
+		if !ok {
+			continue
+		}
+
+		tree, _, err := c.client.Git.GetTree(ctx, c.org, repoName, sha, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to read %s/%s: %v\n", repoName, dir, err)
+			continue
+		}
+
+		levelFiles, levelDirs := collectTreeEntries(tree.Entries, dir, strings.Count(dir, "/")+2)
+		files = append(files, levelFiles...)
+		dirs = append(dirs, levelDirs...)
+
+		for _, entry := range tree.Entries {
+			if entry.GetType() == "tree" {
+				shas[dir+"/"+entry.GetPath()] = entry.GetSHA()
+			}
+		}
+	}
+
+	return files, dirs
+}
+
+// Splits tree entries into file paths and directory paths, prefixing them with base
+// and dropping anything deeper than maxDepth or inside an ignored directory.
+// Files includes the directories too, matching what detectors have always received.
+func collectTreeEntries(entries []*github.TreeEntry, base string, maxDepth int) ([]string, []string) {
+	var files, dirs []string
+	for _, entry := range entries {
+		path := entry.GetPath()
+		if base != "" {
+			path = base + "/" + path
+		}
+
+		if strings.Count(path, "/")+1 > maxDepth || isIgnoredPath(path) {
+			continue
+		}
+
+		files = append(files, path)
+		if entry.GetType() == "tree" {
+			dirs = append(dirs, path)
+		}
+	}
+	return files, dirs
+}
+
+// Walks a tree one level per API call, for repos whose recursive tree GitHub truncated.
+// Stops after maxTreeWalkCalls calls; partial reports that directories were left unread.
+func (c *Client) walkTree(ctx context.Context, repoName, ref string) (files, dirs []string, partial bool, err error) {
+
+	type level struct {
+		sha  string
+		path string
+	}
+	queue := []level{{sha: ref}}
+
+	for calls := 0; len(queue) > 0; calls++ {
+		if calls == maxTreeWalkCalls {
+			fmt.Fprintf(os.Stderr, "Warning: %s: stopped walking the tree after %d calls, %d directories unread\n", repoName, calls, len(queue))
+			return files, dirs, true, nil
+		}
+		current := queue[0]
+		queue = queue[1:]
+
+		tree, _, err := c.client.Git.GetTree(ctx, c.org, repoName, current.sha, false)
+		if err != nil {
+			if current.path == "" {
+				return nil, nil, false, fmt.Errorf("walkTree error: %w", err)
+			}
+			// Keep what we have; one unreadable subtree shouldn't drop the repo
+			fmt.Fprintf(os.Stderr, "Warning: failed to read %s/%s: %v\n", repoName, current.path, err)
+			continue
+		}
+
+		levelFiles, levelDirs := collectTreeEntries(tree.Entries, current.path, c.maxDepth)
+		files = append(files, levelFiles...)
+		dirs = append(dirs, levelDirs...)
+
+		// Queue subdirectories that can still have entries within maxDepth
+		for _, entry := range tree.Entries {
+			if entry.GetType() != "tree" {
+				continue
+			}
+			path := entry.GetPath()
+			if current.path != "" {
+				path = current.path + "/" + path
+			}
+			if strings.Count(path, "/")+1 < c.maxDepth && !ignoredTreeDirs[entry.GetPath()] && !isIgnoredPath(path) {
+				queue = append(queue, level{sha: entry.GetSHA(), path: path})
+			}
+		}
+	}
+
+	return files, dirs, false, nil
+}
+
+// Reports whether the path sits inside a directory we never scan (the directory itself is still listed)
+func isIgnoredPath(path string) bool {
+	segments := strings.Split(path, "/")
+	for _, segment := range segments[:len(segments)-1] {
+		if ignoredTreeDirs[segment] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// Stub git trees API: trees by SHA (or branch name), recording which ones were requested
+type treeStub struct {
+	trees     map[string][]*github.TreeEntry
+	recursive []*github.TreeEntry // answer to a recursive request for any ref
+	truncated bool
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *treeStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sha := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	s.mu.Lock()
+	s.requests = append(s.requests, sha)
+	s.mu.Unlock()
+
+	tree := &github.Tree{SHA: github.String(sha), Truncated: github.Bool(false)}
+	if r.URL.Query().Get("recursive") != "" && s.recursive != nil {
+		tree.Entries, tree.Truncated = s.recursive, github.Bool(s.truncated)
+	} else if entries, ok := s.trees[sha]; ok {
+		tree.Entries = entries
+	} else {
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(tree)
+}
+
+func blob(path string) *github.TreeEntry {
+	return &github.TreeEntry{Path: github.String(path), Type: github.String("blob")}
+}
+
+func subtree(path, sha string) *github.TreeEntry {
+	return &github.TreeEntry{Path: github.String(path), Type: github.String("tree"), SHA: github.String(sha)}
+}
+
+func newTreeTestClient(t *testing.T, stub *treeStub, recursive bool, maxDepth int) *Client {
+	t.Helper()
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	gh := github.NewClient(nil)
+	gh.BaseURL, _ = url.Parse(server.URL + "/")
+	return &Client{client: gh, org: "org", workers: 1, recursive: recursive, maxDepth: maxDepth}
+}
+
+func TestGetFileTreeCalls(t *testing.T) {
+	withCI := map[string][]*github.TreeEntry{
+		"main":      {blob("README.md"), subtree(".github", "gh"), subtree(".circleci", "circle"), subtree("src", "src")},
+		"gh":        {blob("CODEOWNERS"), subtree("workflows", "workflows")},
+		"workflows": {blob("deploy.yml")},
+		"circle":    {blob("config.yml")},
+		"src":       {blob("main.go")},
+	}
+
+	tests := []struct {
+		name      string
+		stub      *treeStub
+		recursive bool
+		wantCalls []string
+		wantFile  string
+	}{
+		{
+			name:      "root only expands the CI dirs",
+			stub:      &treeStub{trees: withCI},
+			wantCalls: []string{"main", "gh", "workflows", "circle"},
+			wantFile:  ".github/workflows/deploy.yml",
+		},
+		{
+			name:      "root only without CI dirs",
+			stub:      &treeStub{trees: map[string][]*github.TreeEntry{"main": {blob("README.md"), subtree("src", "src")}}},
+			wantCalls: []string{"main"},
+			wantFile:  "README.md",
+		},
+		{
+			name: "recursive is one call",
+			stub: &treeStub{recursive: []*github.TreeEntry{
+				blob("README.md"), subtree(".github", "gh"), subtree(".github/workflows", "workflows"), blob(".github/workflows/deploy.yml"),
+			}},
+			recursive: true,
+			wantCalls: []string{"main"},
+			wantFile:  ".github/workflows/deploy.yml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTreeTestClient(t, tt.stub, tt.recursive, DefaultMaxDepth)
+
+			files, _, partial, err := client.getFileTree(t.Context(), "api", "main")
+			if err != nil || partial {
+				t.Fatalf("getFileTree: partial %v, err %v", partial, err)
+			}
+			if !slices.Equal(tt.stub.requests, tt.wantCalls) {
+				t.Errorf("GetTree calls = %v, want %v", tt.stub.requests, tt.wantCalls)
+			}
+			if !slices.Contains(files, tt.wantFile) {
+				t.Errorf("files = %v, want %s listed", files, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestWalkTreeStopsAtCallCap(t *testing.T) {
+	// A truncated recursive tree whose root has more directories than the walk may read
+	const wide = maxTreeWalkCalls + 50
+	stub := &treeStub{trees: map[string][]*github.TreeEntry{}, recursive: []*github.TreeEntry{blob("README.md")}, truncated: true}
+	for i := range wide {
+		sha := fmt.Sprintf("d%d", i)
+		stub.trees["main"] = append(stub.trees["main"], subtree(sha, sha))
+		stub.trees[sha] = []*github.TreeEntry{blob("main.go")}
+	}
+	client := newTreeTestClient(t, stub, true, DefaultMaxDepth)
+
+	files, _, partial, err := client.getFileTree(t.Context(), "monorepo", "main")
+	if err != nil {
+		t.Fatalf("getFileTree: %v", err)
+	}
+	if !partial {
+		t.Error("partial = false, want the capped walk reported")
+	}
+	// One truncated recursive request, then the capped walk
+	if got, want := len(stub.requests), 1+maxTreeWalkCalls; got != want {
+		t.Errorf("GetTree calls = %d, want %d", got, want)
+	}
+	if !slices.Contains(files, "d0/main.go") || slices.Contains(files, fmt.Sprintf("d%d/main.go", wide-1)) {
+		t.Errorf("files should hold the first directories read and not the last")
+	}
+}
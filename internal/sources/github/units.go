@@ -0,0 +1,81 @@
+package github
+
+import (
+	"strings"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Splits a monorepo into one ResourceInfo per deployable unit (see Detector.DeployableDirs).
+// Units inherit the repo-level facts (CI/CD, last commit, CODEOWNERS presence) and get their own
+// platform, tests and owners; repo-wide findings stay on the repo's own row.
+func (ds *DataSource) unitResources(repo *Repository, info *inventory.ResourceInfo, contents map[string]string) []*inventory.ResourceInfo {
+	var units []*inventory.ResourceInfo
+	for _, dir := range ds.detector.DeployableDirs(repo.Files) {
+		files := filesUnder(repo.Files, dir)
+		unitContents := contentsFor(contents, dir)
+
+		unit := *info
+		unit.AppName = repo.Name + "/" + dir
+		unit.ParentRepo = repo.Name
+		unit.UnitPath = dir
+
+		deployment := ds.detector.DetectDeployment(files, unitContents)
+		unit.Platform = strings.Join(deployment.Platforms, ", ")
+		unit.PlatformConfidence = deployment.Confidence
+		unit.DeployEnvironments = deployment.Environments
+		unit.DeployEvidence = deployment.Evidence
+
+		ds.detectTests(&unit, files, unitContents)
+
+		// The unit's owners are whoever CODEOWNERS gives its directory
+		owners := info.PathOwners[dir]
+		unit.CodeOwners = owners
+		unit.RootOwners = nil
+		unit.PathOwners = nil
+		unit.UnownedPaths = nil
+		unit.CodeOwnersErrors = nil
+		unit.CodeOwnersChecked = false
+		unit.OwnerDetails = ownerDetailsFor(info.OwnerDetails, owners)
+		unit.Owner, unit.Team = "Unknown", "Unknown"
+		if len(owners) > 0 {
+			unit.Owner, unit.Team = owners[0], owners[0]
+		}
+
+		units = append(units, &unit)
+	}
+	return units
+}
+
+// Returns the files inside dir (full paths), dir included
+func filesUnder(files []string, dir string) []string {
+	var under []string
+	for _, file := range files {
+		if file == dir || strings.HasPrefix(file, dir+"/") {
+			under = append(under, file)
+		}
+	}
+	return under
+}
+
+// Returns the fetched files inside dir, plus the CI configs that mention it (a job with working-directory: dir)
+func contentsFor(contents map[string]string, dir string) map[string]string {
+	unitContents := make(map[string]string)
+	for file, content := range contents {
+		if strings.HasPrefix(file, dir+"/") || (isCIConfig(file) && strings.Contains(content, dir)) {
+			unitContents[file] = content
+		}
+	}
+	return unitContents
+}
+
+// Picks the resolved details of the given owners
+func ownerDetailsFor(details []inventory.OwnerDetail, owners []string) []inventory.OwnerDetail {
+	var picked []inventory.OwnerDetail
+	for _, detail := range details {
+		if containsPath(owners, detail.Handle) {
+			picked = append(picked, detail)
+		}
+	}
+	return picked
+}
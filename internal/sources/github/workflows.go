@@ -0,0 +1,112 @@
+package github
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/ervinmplayon/tractatus/internal/inventory"
+)
+
+// Where CI configs name the environment a job deploys to
+var environmentPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?m)^\s*environment:[ \t]*['"]?([A-Za-z][\w.-]*)`),                 // GitHub Actions / GitLab short form
+	regexp.MustCompile(`(?m)^\s*environment:[ \t]*\n\s+name:[ \t]*['"]?([A-Za-z][\w.-]*)`), // environment: { name: ... }
+	regexp.MustCompile(`--stage[ =]['"]?([A-Za-z][\w-]*)`),                                 // serverless deploy --stage prod
+	regexp.MustCompile(`--config-env[ =]['"]?([A-Za-z][\w-]*)`),                            // sam deploy --config-env prod
+	regexp.MustCompile(`\beb deploy\s+([A-Za-z][\w-]*)`),                                   // eb deploy my-env-prod
+}
+
+// Where a repo deploys to, as far as the repo itself tells
+type Deployment struct {
+	Platforms    []string // most confident platforms only, "Unknown" if none
+	Confidence   string   // one of the inventory.Confidence* levels, empty when Unknown
+	Environments []string // sorted
+	Evidence     []string // "<rule> in <file>" for each platform rule that counted
+}
+
+// Determines the deployment platforms from platform rules, keeping only the most confident tier,
+// and reads deploy environments out of the CI configs in contents
+func (d *Detector) DetectDeployment(files []string, contents map[string]string) Deployment {
+	deployment := Deployment{Environments: deployEnvironments(contents)}
+
+	seen := make(map[string]bool)
+	for _, rule := range d.rules {
+		if rule.Detects != RulePlatform {
+			continue
+		}
+		evidence := rule.evidence(files, contents)
+		if len(evidence) == 0 {
+			continue
+		}
+
+		confidence := rule.Confidence
+		if confidence == "" {
+			confidence = inventory.ConfidenceMedium
+		}
+		switch {
+		case confidenceRank(confidence) > confidenceRank(deployment.Confidence):
+			// A better tier replaces what we have
+			deployment.Confidence = confidence
+			deployment.Platforms = nil
+			deployment.Evidence = nil
+			seen = make(map[string]bool)
+		case confidence != deployment.Confidence:
+			continue
+		}
+
+		deployment.Evidence = append(deployment.Evidence, rule.Name+" in "+evidence[0])
+		if !seen[rule.Value] {
+			seen[rule.Value] = true
+			deployment.Platforms = append(deployment.Platforms, rule.Value)
+		}
+	}
+
+	if len(deployment.Platforms) == 0 {
+		deployment.Platforms = []string{"Unknown"}
+	}
+	return deployment
+}
+
+// Orders confidence levels; anything unknown ranks lowest
+func confidenceRank(confidence string) int {
+	switch confidence {
+	case inventory.ConfidenceHigh:
+		return 3
+	case inventory.ConfidenceMedium:
+		return 2
+	case inventory.ConfidenceLow:
+		return 1
+	}
+	return 0
+}
+
+// Collects the environment names CI configs deploy to, sorted
+func deployEnvironments(contents map[string]string) []string {
+	seen := make(map[string]bool)
+	var environments []string
+	for file, content := range contents {
+		if !isCIConfig(file) {
+			continue
+		}
+		for _, pattern := range environmentPatterns {
+			for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+				if env := match[1]; !seen[env] {
+					seen[env] = true
+					environments = append(environments, env)
+				}
+			}
+		}
+	}
+	sort.Strings(environments)
+	return environments
+}
+
+// Reports whether a path is a CI configuration file deploy rules read
+func isCIConfig(file string) bool {
+	for _, pattern := range ciConfigGlobs {
+		if matchesGlob(file, pattern) {
+			return true
+		}
+	}
+	return false
+}